@@ -2,10 +2,20 @@ package aocutils
 
 import (
 	"bufio"
+	"cmp"
+	"container/heap"
+	"container/list"
 	"fmt"
+	"iter"
+	"math"
+	"math/bits"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // File Utils
@@ -43,7 +53,20 @@ func ReadLines(filename string) (lines []string) {
 	return
 }
 
+// splitRow splits a line on delim, or into one string per rune if delim is empty.
+func splitRow(line string, delim string) []string {
+	if delim != "" {
+		return strings.Split(line, delim)
+	}
+	row := make([]string, 0, len(line))
+	for _, r := range line {
+		row = append(row, string(r))
+	}
+	return row
+}
+
 // ReadGrid attempts to read a grid from a file usign a given delimeter.
+// An empty delimeter splits each line into its individual runes, so multi-byte characters stay intact.
 // It will panic if there are any issues opening or reading the file.
 // It returns a slice of slices of strings ([][]string)
 func ReadGrid(filename string, delim string) (grid Grid[string]) {
@@ -51,13 +74,14 @@ func ReadGrid(filename string, delim string) (grid Grid[string]) {
 	defer file.Close()
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		row := strings.Split(scanner.Text(), delim)
+		row := splitRow(scanner.Text(), delim)
 		grid = append(grid, row)
 	}
 	return
 }
 
 // ReadNumberGrid attempts to read a grid of numbers from a file using a given delimeter
+// An empty delimeter treats every rune as a single-digit number.
 // It will panic if there are any issues opening or reading the file.
 // It returns a slice of slices of ints ([][]int).
 func ReadNumberGrid(filename string, delim string) (grid Grid[int]) {
@@ -66,7 +90,7 @@ func ReadNumberGrid(filename string, delim string) (grid Grid[int]) {
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		row := make([]int, 0)
-		line := strings.Split(scanner.Text(), delim)
+		line := splitRow(scanner.Text(), delim)
 		for _, val := range line {
 			row = append(row, StrToInt(val))
 		}
@@ -84,6 +108,51 @@ func CheckErr(err error) {
 	}
 }
 
+// CheckErrf checks if the given err is nil, panicing with err wrapped in a formatted message if it isn't.
+// For example CheckErrf(err, "parsing line %d", i) panics with "parsing line 3: <err>".
+func CheckErrf(err error, format string, args ...any) {
+	if err != nil {
+		panic(fmt.Errorf(format+": %w", append(args, err)...))
+	}
+}
+
+// Must returns v if err is nil, panicing otherwise.
+// The panic value is an error wrapping err, so it can be inspected with errors.Is or errors.As after a recover.
+func Must[T any](v T, err error) T {
+	if err != nil {
+		panic(fmt.Errorf("Must: %w", err))
+	}
+	return v
+}
+
+// Must2 returns a and b if err is nil, panicing otherwise.
+// The panic value is an error wrapping err, so it can be inspected with errors.Is or errors.As after a recover.
+func Must2[A, B any](a A, b B, err error) (A, B) {
+	if err != nil {
+		panic(fmt.Errorf("Must2: %w", err))
+	}
+	return a, b
+}
+
+// Timing
+
+// Time runs f and prints how long it took as "name: <duration>" to stderr,
+// so the timing doesn't get mixed up with answers printed to stdout.
+func Time(name string, f func()) {
+	start := time.Now()
+	f()
+	fmt.Fprintf(os.Stderr, "%s: %v\n", name, time.Since(start))
+}
+
+// TimeResult runs f and prints how long it took as "name: <duration>" to stderr.
+// It returns the result of f.
+func TimeResult[T any](name string, f func() T) T {
+	start := time.Now()
+	result := f()
+	fmt.Fprintf(os.Stderr, "%s: %v\n", name, time.Since(start))
+	return result
+}
+
 // Conversions
 
 // StrToInt attempts to convert a given string to an int.
@@ -102,138 +171,4029 @@ func IntToStr(num int) (s string) {
 	return
 }
 
-// Math
+// AtoiSlice converts every string in a slice to an int, skipping empty strings.
+// It will panic if any string cannot be converted.
+// It returns a slice of ints.
+func AtoiSlice(ss []string) []int {
+	nums, err := TryAtoiSlice(ss)
+	CheckErr(err)
+	return nums
+}
 
-// Abs returns an int representing the aboslute value of an integer
-func Abs(x int) int {
-	if x < 0 {
-		return -x
+// TryAtoiSlice converts every string in a slice to an int, skipping empty strings.
+// It returns a slice of ints, or an error naming the index of the first string that cannot be converted.
+func TryAtoiSlice(ss []string) ([]int, error) {
+	nums := make([]int, 0, len(ss))
+	for i, s := range ss {
+		if s == "" {
+			continue
+		}
+		num, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		nums = append(nums, num)
 	}
-	return x
+	return nums, nil
 }
 
-// Pow returns an int representing n to the m power
-func Pow(n, m int) int {
-	if m == 0 {
-		return 1
+// ItoaSlice converts every int in a slice to a string.
+// It returns a slice of strings.
+func ItoaSlice(nums []int) []string {
+	ss := make([]string, len(nums))
+	for i, num := range nums {
+		ss[i] = strconv.Itoa(num)
 	}
-	if m == 1 {
-		return n
+	return ss
+}
+
+// StringToRunes converts a string to a slice of runes.
+// It returns a slice of runes.
+func StringToRunes(s string) []rune {
+	return []rune(s)
+}
+
+// RunesToString converts a slice of runes to a string.
+// It returns a string.
+func RunesToString(runes []rune) string {
+	return string(runes)
+}
+
+// RuneToDigit converts a rune from '0' to '9' to its numeric value.
+// It will panic if the rune is not a decimal digit.
+// It returns an int.
+func RuneToDigit(r rune) int {
+	d, ok := TryRuneToDigit(r)
+	if !ok {
+		panic(fmt.Sprintf("RuneToDigit: %q is not a digit", r))
 	}
-	result := n
-	for i := 2; i <= m; i++ {
-		result *= n
+	return d
+}
+
+// TryRuneToDigit converts a rune from '0' to '9' to its numeric value.
+// It returns an int and true, or 0 and false if the rune is not a decimal digit.
+func TryRuneToDigit(r rune) (int, bool) {
+	if r < '0' || r > '9' {
+		return 0, false
 	}
-	return result
+	return int(r - '0'), true
 }
 
-// Array Utils
-// Shamelessly copied from https://go.dev/wiki/SliceTricks
+// DigitToRune converts a number from 0 to 9 to its digit rune.
+// It will panic if d is not a single decimal digit.
+// It returns a rune.
+func DigitToRune(d int) rune {
+	if d < 0 || d > 9 {
+		panic(fmt.Sprintf("DigitToRune: %d is not a single digit", d))
+	}
+	return rune('0' + d)
+}
 
-// Cut removes a range of elements from a slice of type T
-// It returns a new slice of type T.
-func Cut[T any](slice []T, start, end int) []T {
-	copy(slice[start:], slice[end:])
-	for k, n := len(slice)-end+start, len(slice); k < n; k++ {
-		slice[k] = *new(T)
+// LetterIndex converts a letter from 'a' to 'z' or 'A' to 'Z' to its position in the alphabet, from 0 to 25.
+// It will panic if the rune is not an ASCII letter.
+// It returns an int.
+func LetterIndex(r rune) int {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return int(r - 'a')
+	case r >= 'A' && r <= 'Z':
+		return int(r - 'A')
 	}
-	return slice[:len(slice)-end+start]
+	panic(fmt.Sprintf("LetterIndex: %q is not a letter", r))
 }
 
-// Delete removes an element at a given index from a slice of type T,
-// while preserving the original order of the slice.
-// It returns a new slice of type T.
-func Delete[T any](slice []T, index int) []T {
-	copy(slice[index:], slice[index+1:])
-	slice[len(slice)-1] = *new(T)
-	return slice[:len(slice)-1]
+// BinToInt converts a string of '0' and '1' characters to an int.
+// It will panic if the string contains any other character or is longer than 63 bits.
+// It returns an int.
+func BinToInt(s string) (num int) {
+	if len(s) > 63 {
+		panic(fmt.Sprintf("BinToInt: %q overflows int (%d bits > 63)", s, len(s)))
+	}
+	for i, r := range s {
+		switch r {
+		case '0':
+			num <<= 1
+		case '1':
+			num = num<<1 | 1
+		default:
+			panic(fmt.Sprintf("BinToInt: invalid binary digit %q at index %d", r, i))
+		}
+	}
+	return
 }
 
-// Insert adds an element at a given index to a slice of type T
-// It returns a new slice of type T.
-func Insert[T any](slice []T, element T, index int) []T {
-	slice = append(slice, *new(T))
-	copy(slice[index+1:], slice[index:])
-	slice[index] = element
-	return slice
+// IntToBin converts a non-negative int to a binary string zero-padded to the given width.
+// Numbers needing more than width digits are not truncated.
+// It will panic if num is negative.
+// It returns a string.
+func IntToBin(num, width int) string {
+	if num < 0 {
+		panic(fmt.Sprintf("IntToBin: num must be non-negative, got %d", num))
+	}
+	return fmt.Sprintf("%0*b", width, num)
 }
 
-// A type representing a slice of type T.
-type Stack[T any] []T
+// BitsToInt converts a slice of bools to an int, treating bits[0] as the most significant bit.
+// It will panic if there are more than 63 bits.
+// It returns an int.
+func BitsToInt(bits []bool) (num int) {
+	if len(bits) > 63 {
+		panic(fmt.Sprintf("BitsToInt: %d bits overflows int (> 63)", len(bits)))
+	}
+	for _, bit := range bits {
+		num <<= 1
+		if bit {
+			num |= 1
+		}
+	}
+	return
+}
 
-// Push adds the given elements to the end of a stack of type T.
-func (s Stack[T]) Push(elements ...T) Stack[T] {
-	return append(s, elements...)
+// IntToBits converts a non-negative int to a slice of bools zero-padded to the given width,
+// with the most significant bit first.
+// Numbers needing more than width bits are not truncated.
+// It will panic if num is negative.
+// It returns a slice of bools.
+func IntToBits(num, width int) []bool {
+	s := IntToBin(num, width)
+	bits := make([]bool, len(s))
+	for i := range s {
+		bits[i] = s[i] == '1'
+	}
+	return bits
 }
 
-// Pop removes an element from the end of a stack of type T.
-// It returns the removed element.
-func (s Stack[T]) Pop() (Stack[T], T) {
-	if len(s) == 0 {
-		return s, *new(T)
+// BoolsToString renders a slice of bools as a string, using on for true and off for false.
+// It returns a string.
+func BoolsToString(bits []bool, on, off rune) string {
+	runes := make([]rune, len(bits))
+	for i, bit := range bits {
+		runes[i] = off
+		if bit {
+			runes[i] = on
+		}
 	}
-	return s[:len(s)-1], s[len(s)-1]
+	return string(runes)
 }
 
-// Unshift adds an element to the beginning of a stack of type T.
-func (s Stack[T]) Unshift(element T) Stack[T] {
-	return append([]T{element}, s...)
+// HexToBinStr converts a hexadecimal string to a binary string, mapping each hex digit
+// to exactly four binary digits so leading zeros are preserved ("0F" becomes "00001111").
+// It returns a string, or an error naming the first invalid rune and its index.
+func HexToBinStr(s string) (string, error) {
+	var sb strings.Builder
+	for i, r := range s {
+		d, err := strconv.ParseUint(string(r), 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("HexToBinStr: invalid hex digit %q at index %d", r, i)
+		}
+		fmt.Fprintf(&sb, "%04b", d)
+	}
+	return sb.String(), nil
 }
 
-// Shift removes an element from the beginning of a stack of type T.
-// It returns the removed element.
-func (s Stack[T]) Shift() (Stack[T], T) {
-	return s[1:], s[0]
+// BinStrToHex converts a binary string whose length is a multiple of four to an uppercase
+// hexadecimal string, mapping each group of four binary digits to one hex digit.
+// It returns a string, or an error naming the first invalid rune and its index.
+func BinStrToHex(s string) (string, error) {
+	if len(s)%4 != 0 {
+		return "", fmt.Errorf("BinStrToHex: length %d is not a multiple of 4", len(s))
+	}
+	var sb strings.Builder
+	d := 0
+	for i, r := range s {
+		if r != '0' && r != '1' {
+			return "", fmt.Errorf("BinStrToHex: invalid binary digit %q at index %d", r, i)
+		}
+		d = d<<1 | int(r-'0')
+		if i%4 == 3 {
+			fmt.Fprintf(&sb, "%X", d)
+			d = 0
+		}
+	}
+	return sb.String(), nil
 }
 
-// Grid Utils
+// ParseBase converts a string to an int using a custom digit alphabet,
+// where the base is the length of digits and each rune's value is its index in digits.
+// For example ParseBase("ba", "ab") returns 2.
+// It will panic if s contains a rune not in digits or the result overflows an int.
+// It returns an int.
+func ParseBase(s string, digits string) int {
+	values := make(map[rune]int)
+	for i, r := range []rune(digits) {
+		values[r] = i
+	}
+	return ParseBaseMap(s, values, len(values))
+}
 
-// A type representing a slice of slices of type T
-type Grid[T any] [][]T
+// FormatBase converts a non-negative int to a string using a custom digit alphabet,
+// where the base is the length of digits and each rune's value is its index in digits.
+// It will panic if num is negative or digits has fewer than two runes.
+// It returns a string.
+func FormatBase(num int, digits string) string {
+	alphabet := []rune(digits)
+	if len(alphabet) < 2 {
+		panic(fmt.Sprintf("FormatBase: need at least two digits, got %q", digits))
+	}
+	if num < 0 {
+		panic(fmt.Sprintf("FormatBase: num must be non-negative, got %d", num))
+	}
+	values := make(map[rune]int)
+	for i, r := range alphabet {
+		values[r] = i
+	}
+	return FormatBaseMap(num, values, len(alphabet))
+}
 
-// A type representing an X and Y coordinate pair
-type Coordinate struct{ X, Y int }
+// ParseBaseMap converts a string to an int in the given base, using values to map each rune to its digit value.
+// Digit values may be negative, which allows balanced bases such as SNAFU
+// (values {'=': -2, '-': -1, '0': 0, '1': 1, '2': 2} in base 5).
+// It will panic if s contains a rune not in values or the result overflows an int.
+// It returns an int.
+func ParseBaseMap(s string, values map[rune]int, base int) (num int) {
+	for i, r := range s {
+		d, ok := values[r]
+		if !ok {
+			panic(fmt.Sprintf("ParseBaseMap: invalid digit %q at index %d", r, i))
+		}
+		if num > math.MaxInt/base || num < math.MinInt/base {
+			panic(fmt.Sprintf("ParseBaseMap: %q overflows int", s))
+		}
+		num *= base
+		if (d > 0 && num > math.MaxInt-d) || (d < 0 && num < math.MinInt-d) {
+			panic(fmt.Sprintf("ParseBaseMap: %q overflows int", s))
+		}
+		num += d
+	}
+	return
+}
 
-type Direction int
+// FormatBaseMap converts an int to a string in the given base, using values to map each rune to its digit value.
+// The digit values must contain exactly one value from each residue class modulo base,
+// which holds for both standard (0 to base-1) and balanced alphabets.
+// Negative numbers can only be written with an alphabet that has a negative digit.
+// It will panic if no digit matches a required residue, if num is negative and every digit value is
+// non-negative, or if the digit values can't represent num at all.
+// It returns a string.
+func FormatBaseMap(num int, values map[rune]int, base int) string {
+	byResidue := make(map[int]rune)
+	hasNegative := false
+	for r, d := range values {
+		byResidue[((d%base)+base)%base] = r
+		hasNegative = hasNegative || d < 0
+	}
+	if num < 0 && !hasNegative {
+		panic(fmt.Sprintf("FormatBaseMap: cannot format negative %d without a negative digit", num))
+	}
+	digitFor := func(residue int) rune {
+		r, ok := byResidue[residue]
+		if !ok {
+			panic(fmt.Sprintf("FormatBaseMap: no digit with value %d mod %d", residue, base))
+		}
+		return r
+	}
+	if num == 0 {
+		return string(digitFor(0))
+	}
+	out := make([]rune, 0)
+	seen := make(map[int]bool)
+	for rest := num; rest != 0; {
+		if seen[rest] {
+			panic(fmt.Sprintf("FormatBaseMap: digit values cannot represent %d in base %d", num, base))
+		}
+		seen[rest] = true
+		q, rem := rest/base, rest%base
+		r := digitFor((rem + base) % base)
+		out = append(out, r)
+		rest = q + (rem-values[r])/base
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
 
-const (
-	N Direction = iota
-	NE
-	E
-	SE
-	S
-	SW
-	W
-	NW
-)
+// Parsing
 
-var Offsets = map[Direction]Coordinate{
-	N:  {X: 0, Y: -1},
-	NE: {X: 1, Y: -1},
-	E:  {X: 1, Y: 0},
-	SE: {X: 1, Y: 1},
-	S:  {X: 0, Y: 1},
-	SW: {X: -1, Y: 1},
-	W:  {X: -1, Y: 0},
-	NW: {X: -1, Y: -1},
+// Parse matches a whole line against a pattern and captures the values of its placeholders.
+// Placeholders are %d for a signed integer, %s for a run of non-space characters,
+// %v for any text (including spaces), and %% for a literal percent sign.
+// All other characters in the pattern must match exactly.
+// For example Parse("move 3 from 1 to 2", "move %d from %d to %d") returns ["3", "1", "2"].
+// It returns a slice of strings, or an error if the pattern is invalid or the line doesn't match.
+func Parse(line, pattern string) ([]string, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			j := strings.IndexByte(pattern[i:], '%')
+			if j < 0 {
+				j = len(pattern) - i
+			}
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+j]))
+			i += j - 1
+			continue
+		}
+		if i+1 == len(pattern) {
+			return nil, fmt.Errorf("Parse: pattern %q ends with '%%'", pattern)
+		}
+		i++
+		switch pattern[i] {
+		case 'd':
+			sb.WriteString(`([-+]?\d+)`)
+		case 's':
+			sb.WriteString(`(\S+)`)
+		case 'v':
+			sb.WriteString(`(.*?)`)
+		case '%':
+			sb.WriteString("%")
+		default:
+			return nil, fmt.Errorf("Parse: unknown placeholder %%%c in pattern %q", pattern[i], pattern)
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("Parse: %w", err)
+	}
+	match := re.FindStringSubmatch(line)
+	if match == nil {
+		return nil, fmt.Errorf("Parse: %q does not match pattern %q", line, pattern)
+	}
+	return match[1:], nil
 }
 
-// InBounds checks if the given coordinates are in the bounds of a given grid.
-// The grid is assumed to be square
-// It returns a bool.
-func InBounds[T any](grid Grid[T], coord Coordinate) bool {
-	return coord.Y >= 0 && coord.X >= 0 && coord.Y <= len(grid)-1 && coord.X <= len(grid[0])-1
+// MustParse matches a whole line against a pattern and captures the values of its placeholders,
+// as described by Parse.
+// It will panic if the pattern is invalid or the line doesn't match.
+// It returns a slice of strings.
+func MustParse(line, pattern string) []string {
+	fields, err := Parse(line, pattern)
+	CheckErr(err)
+	return fields
 }
 
-// PrintGrid prints every element in a given grid separated by a given delimeter.
-func PrintGrid[T any](grid Grid[T], delim string) {
-	for _, row := range grid {
-		fmt.Print(delim)
-		for _, col := range row {
-			fmt.Print(col, delim)
+// SplitAny splits a string on every rune found in delims, dropping empty fields.
+// For example SplitAny("1-3 a: abcde", "- :") returns ["1", "3", "a", "abcde"].
+// It returns a slice of strings.
+func SplitAny(s string, delims string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune(delims, r)
+	})
+}
+
+// SplitAnyInts splits a string on every rune found in delims, dropping empty fields,
+// and converts each field to an int.
+// It will panic if any field cannot be converted.
+// It returns a slice of ints.
+func SplitAnyInts(s string, delims string) []int {
+	return AtoiSlice(SplitAny(s, delims))
+}
+
+var intPattern = regexp.MustCompile(`-?\d+`)
+
+// ExtractInts finds every integer in a string, ignoring any other text. A leading '-' makes
+// an integer negative, so "x=-3, y=+4" gives [-3, 4].
+// It returns a slice of ints in the order they appear.
+func ExtractInts(s string) []int {
+	nums := make([]int, 0)
+	for _, match := range intPattern.FindAllString(s, -1) {
+		nums = append(nums, StrToInt(match))
+	}
+	return nums
+}
+
+// A type representing an instruction with an opcode and integer arguments, such as "jmp -4".
+type Instruction struct {
+	Op   string
+	Args []int
+}
+
+// A type representing an instruction whose arguments are kept as strings, such as "cpy a b" or "jio a, +19".
+type RawInstruction struct {
+	Op   string
+	Args []string
+}
+
+// ParseInstructions parses each line into its leading opcode word and the integers found in the rest
+// of the line, as described by ExtractInts, so "acc +3" becomes {Op: "acc", Args: [3]}.
+// It will panic if a line is blank.
+// It returns a slice of Instructions, one per line.
+func ParseInstructions(lines []string) []Instruction {
+	instructions := make([]Instruction, 0, len(lines))
+	for _, raw := range ParseRawInstructions(lines) {
+		instructions = append(instructions, Instruction{Op: raw.Op, Args: ExtractInts(strings.Join(raw.Args, " "))})
+	}
+	return instructions
+}
+
+// ParseRawInstructions parses each line into its leading opcode word and the remaining fields,
+// split on spaces and commas, for instruction sets that name registers.
+// So "jio a, +19" becomes {Op: "jio", Args: ["a", "+19"]}.
+// It will panic if a line is blank.
+// It returns a slice of RawInstructions, one per line.
+func ParseRawInstructions(lines []string) []RawInstruction {
+	instructions := make([]RawInstruction, 0, len(lines))
+	for i, line := range lines {
+		fields := SplitAny(line, " \t,")
+		if len(fields) == 0 {
+			panic(fmt.Sprintf("ParseRawInstructions: line %d is blank", i))
 		}
-		println()
+		instructions = append(instructions, RawInstruction{Op: fields[0], Args: fields[1:]})
+	}
+	return instructions
+}
+
+// MatchBrackets checks that the brackets in a string are balanced, where pairs maps each opening bracket
+// to its closing bracket, such as {'(': ')', '[': ']'}. Runes that aren't brackets are ignored.
+// If a closing bracket doesn't match the innermost open bracket, the string is corrupt and that closing
+// bracket is returned as corruptFirst. Otherwise any brackets left open are returned as incomplete,
+// innermost first, so mapping each through pairs gives the closing sequence that completes the string.
+// It returns ok as true only if the string is neither corrupt nor incomplete.
+func MatchBrackets(s string, pairs map[rune]rune) (corruptFirst rune, incomplete []rune, ok bool) {
+	openers := make(map[rune]rune, len(pairs))
+	for opener, closer := range pairs {
+		openers[closer] = opener
+	}
+	var open Stack[rune]
+	for _, r := range s {
+		if _, isOpener := pairs[r]; isOpener {
+			open.Push(r)
+			continue
+		}
+		want, isCloser := openers[r]
+		if !isCloser {
+			continue
+		}
+		if got, found := open.TryPop(); !found || got != want {
+			return r, nil, false
+		}
+	}
+	if open.IsEmpty() {
+		return 0, nil, true
+	}
+	slices.Reverse(open)
+	return 0, open, false
+}
+
+// ParseRange parses a range of two signed integers separated by sep, such as "3-7" with sep "-",
+// "x=10..14" with sep "..", or "-5..-2" with sep "..". Non-numeric text around each bound is ignored.
+// It will panic if either side doesn't contain exactly one integer, or if lo is greater than hi.
+// It returns the lower and upper bounds.
+func ParseRange(s string, sep string) (lo, hi int) {
+	lo, hi = parseRange(s, sep)
+	if lo > hi {
+		panic(fmt.Sprintf("ParseRange: lower bound %d is greater than upper bound %d in %q", lo, hi, s))
+	}
+	return
+}
+
+// ParseRangeAnyOrder parses a range like ParseRange, but swaps the bounds if they are given in descending order.
+// It will panic if either side doesn't contain exactly one integer.
+// It returns the lower and upper bounds.
+func ParseRangeAnyOrder(s string, sep string) (lo, hi int) {
+	lo, hi = parseRange(s, sep)
+	return min(lo, hi), max(lo, hi)
+}
+
+// parseRange splits s at the first sep that follows a digit, so a leading minus sign
+// on the upper bound is not mistaken for the separator.
+func parseRange(s string, sep string) (int, int) {
+	start := strings.IndexAny(s, "0123456789")
+	if start < 0 || sep == "" {
+		panic(fmt.Sprintf("ParseRange: cannot split %q on %q", s, sep))
+	}
+	i := strings.Index(s[start:], sep)
+	if i < 0 {
+		panic(fmt.Sprintf("ParseRange: missing %q in %q", sep, s))
+	}
+	left, right := ExtractInts(s[:start+i]), ExtractInts(s[start+i+len(sep):])
+	if len(left) != 1 || len(right) != 1 {
+		panic(fmt.Sprintf("ParseRange: expected one integer on each side of %q in %q", sep, s))
+	}
+	return left[0], right[0]
+}
+
+// Math
+
+// A type constraint matching every integer and floating-point type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Abs returns the absolute value of a number of any integer or floating-point type.
+// Existing callers passing an int get an int back, as before.
+// It will panic for the most negative value of a signed integer type (such as math.MinInt),
+// whose absolute value can't be represented, rather than silently returning a negative number.
+func Abs[T Number](x T) T {
+	if x < 0 {
+		if -x < 0 {
+			panic(fmt.Sprintf("Abs: absolute value of %v overflows", x))
+		}
+		return -x
+	}
+	return x
+}
+
+// AbsDiff returns the absolute difference between a and b, |a - b|.
+// It compares before subtracting, so it doesn't underflow for unsigned types.
+func AbsDiff[T Number](a, b T) T {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// Abs64 returns an int64 representing the absolute value of an int64,
+// for product-heavy puzzles that work in int64 explicitly.
+func Abs64(x int64) int64 {
+	return Abs(x)
+}
+
+// Sign returns -1 if x is negative, 1 if x is positive, and 0 if x is 0.
+// It compares rather than negates, so it is safe for math.MinInt.
+func Sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
+
+// Compare returns -1 if a is less than b, 1 if a is greater than b, and 0 if they are equal.
+// It compares rather than subtracts, so it can't overflow.
+func Compare[T cmp.Ordered](a, b T) int {
+	return cmp.Compare(a, b)
+}
+
+// MinIndex finds the smallest value in a slice and where it is. Ties go to the first occurrence.
+// It will panic if the slice is empty.
+// It returns the index and the value.
+func MinIndex[T cmp.Ordered](vals []T) (idx int, val T) {
+	if len(vals) == 0 {
+		panic("MinIndex: no values")
+	}
+	for i, v := range vals {
+		if v < vals[idx] {
+			idx = i
+		}
+	}
+	return idx, vals[idx]
+}
+
+// MaxIndex finds the largest value in a slice and where it is. Ties go to the first occurrence.
+// It will panic if the slice is empty.
+// It returns the index and the value.
+func MaxIndex[T cmp.Ordered](vals []T) (idx int, val T) {
+	if len(vals) == 0 {
+		panic("MaxIndex: no values")
+	}
+	for i, v := range vals {
+		if v > vals[idx] {
+			idx = i
+		}
+	}
+	return idx, vals[idx]
+}
+
+// MinBy finds the element of a slice whose key is smallest, calling key once per element.
+// Ties go to the first occurrence.
+// It will panic if the slice is empty.
+// It returns the element and its key.
+func MinBy[U any, T cmp.Ordered](vals []U, key func(U) T) (best U, bestKey T) {
+	if len(vals) == 0 {
+		panic("MinBy: no values")
+	}
+	best, bestKey = vals[0], key(vals[0])
+	for _, v := range vals[1:] {
+		if k := key(v); k < bestKey {
+			best, bestKey = v, k
+		}
+	}
+	return
+}
+
+// MaxBy finds the element of a slice whose key is largest, calling key once per element.
+// Ties go to the first occurrence.
+// It will panic if the slice is empty.
+// It returns the element and its key.
+func MaxBy[U any, T cmp.Ordered](vals []U, key func(U) T) (best U, bestKey T) {
+	if len(vals) == 0 {
+		panic("MaxBy: no values")
+	}
+	best, bestKey = vals[0], key(vals[0])
+	for _, v := range vals[1:] {
+		if k := key(v); k > bestKey {
+			best, bestKey = v, k
+		}
+	}
+	return
+}
+
+// Mod returns the Euclidean remainder of a divided by b, which is always in [0, |b|),
+// unlike Go's % operator which takes the sign of a. For example Mod(-1, 5) returns 4.
+// A negative b behaves like its absolute value, so Mod(-1, -5) also returns 4.
+// It will panic if b is 0.
+func Mod(a, b int) int {
+	if b == 0 {
+		panic("Mod: division by zero")
+	}
+	r := a % b
+	if r < 0 {
+		if b > 0 {
+			r += b
+		} else {
+			r -= b
+		}
+	}
+	return r
+}
+
+// DivCeil divides a by b, always rounding toward positive infinity, so DivCeil(7, 2) returns 4
+// and DivCeil(-7, 2) returns -3.
+// It will panic if b is 0.
+func DivCeil(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) == (b < 0) {
+		q++
+	}
+	return q
+}
+
+// DivFloor divides a by b, always rounding toward negative infinity, so DivFloor(7, 2) returns 3
+// and DivFloor(-7, 2) returns -4.
+// It will panic if b is 0.
+func DivFloor(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// DivModEuclid divides a by b with Euclidean semantics: the remainder is the same as Mod(a, b),
+// always in [0, |b|), and the quotient satisfies q*b + r == a. For example DivModEuclid(-7, 2) returns -4, 1.
+// Like Go's / operator, DivModEuclid(math.MinInt, -1) wraps around and returns math.MinInt, 0.
+// It will panic if b is 0.
+func DivModEuclid(a, b int) (q, r int) {
+	if b == 0 {
+		panic("DivModEuclid: division by zero")
+	}
+	q, r = a/b, a%b
+	if r < 0 {
+		if b > 0 {
+			q, r = q-1, r+b
+		} else {
+			q, r = q+1, r-b
+		}
+	}
+	return
+}
+
+// Midpoint returns the midpoint of a and b, rounded toward a.
+// It halves the distance between them as a uint, which holds any difference of two ints,
+// so unlike (a+b)/2 or a+(b-a)/2 it is exact even for math.MinInt and math.MaxInt.
+func Midpoint(a, b int) int {
+	if a <= b {
+		return a + int((uint(b)-uint(a))/2)
+	}
+	return a - int((uint(a)-uint(b))/2)
+}
+
+// WrapIndex wraps an index into [0, length), so -1 refers to the last element of a slice.
+// It will panic if length is not positive.
+func WrapIndex(i, length int) int {
+	if length <= 0 {
+		panic(fmt.Sprintf("WrapIndex: length must be positive, got %d", length))
+	}
+	return Mod(i, length)
+}
+
+// Clamp restricts v to the inclusive range [lo, hi].
+// It will panic if lo is greater than hi.
+func Clamp[T cmp.Ordered](v, lo, hi T) T {
+	if lo > hi {
+		panic(fmt.Sprintf("Clamp: lo %v is greater than hi %v", lo, hi))
+	}
+	return min(max(v, lo), hi)
+}
+
+// ManhattanDist returns the taxicab distance between (x1, y1) and (x2, y2).
+func ManhattanDist(x1, y1, x2, y2 int) int {
+	return Abs(x1-x2) + Abs(y1-y2)
+}
+
+// Chebyshev returns the chessboard distance between (x1, y1) and (x2, y2),
+// the number of king moves needed to get from one to the other.
+func Chebyshev(x1, y1, x2, y2 int) int {
+	return max(Abs(x1-x2), Abs(y1-y2))
+}
+
+// EuclideanSq returns the square of the straight-line distance between (x1, y1) and (x2, y2),
+// which stays in integers and orders points the same way as the true distance.
+func EuclideanSq(x1, y1, x2, y2 int) int {
+	dx, dy := x1-x2, y1-y2
+	return dx*dx + dy*dy
+}
+
+// Min returns the smallest of the given values.
+// A slice can be passed as Min(vals...).
+// It will panic if no values are given.
+func Min[T cmp.Ordered](vals ...T) T {
+	if len(vals) == 0 {
+		panic("Min: no values")
+	}
+	return slices.Min(vals)
+}
+
+// Max returns the largest of the given values.
+// A slice can be passed as Max(vals...).
+// It will panic if no values are given.
+func Max[T cmp.Ordered](vals ...T) T {
+	if len(vals) == 0 {
+		panic("Max: no values")
+	}
+	return slices.Max(vals)
+}
+
+// MinMax finds the smallest and largest of the given values in a single pass.
+// A slice can be passed as MinMax(vals...).
+// It will panic if no values are given.
+func MinMax[T cmp.Ordered](vals ...T) (lo, hi T) {
+	if len(vals) == 0 {
+		panic("MinMax: no values")
+	}
+	lo, hi = vals[0], vals[0]
+	for _, v := range vals[1:] {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	return
+}
+
+// Sum adds up a slice of numbers. The sum of an empty slice is 0.
+func Sum[T Number](vals []T) (total T) {
+	for _, v := range vals {
+		total += v
+	}
+	return
+}
+
+// Product multiplies a slice of numbers together. The product of an empty slice is 1.
+func Product[T Number](vals []T) T {
+	total := T(1)
+	for _, v := range vals {
+		total *= v
+	}
+	return total
+}
+
+// SumFunc adds up f applied to every element of a slice, without building an intermediate slice.
+// The sum of an empty slice is 0.
+func SumFunc[U any, T Number](vals []U, f func(U) T) (total T) {
+	for _, v := range vals {
+		total += f(v)
+	}
+	return
+}
+
+// Mean returns the arithmetic mean of a slice of ints.
+// It will panic if the slice is empty.
+func Mean(vals []int) float64 {
+	if len(vals) == 0 {
+		panic("Mean: no values")
+	}
+	return float64(Sum(vals)) / float64(len(vals))
+}
+
+// Median returns the middle value of a slice of ints, or the mean of the two middle values for an even length.
+// It sorts a copy, leaving the original untouched.
+// It will panic if the slice is empty.
+func Median(vals []int) float64 {
+	if len(vals) == 0 {
+		panic("Median: no values")
+	}
+	sorted := Sorted(vals)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return float64(sorted[mid])
+	}
+	return (float64(sorted[mid-1]) + float64(sorted[mid])) / 2
+}
+
+// Mode finds the most frequent value in a slice of type T. Ties go to the value that appears first.
+// It will panic if the slice is empty.
+// It returns the value and how many times it appears.
+func Mode[T comparable](vals []T) (mode T, count int) {
+	if len(vals) == 0 {
+		panic("Mode: no values")
+	}
+	counts := CountSlice(vals)
+	for _, v := range vals {
+		if counts[v] > count {
+			mode, count = v, counts[v]
+		}
+	}
+	return
+}
+
+// AddOverflows adds a and b.
+// It returns the sum and whether it overflowed an int, in which case the sum has wrapped.
+func AddOverflows(a, b int) (int, bool) {
+	c := a + b
+	return c, (a > 0 && b > 0 && c < 0) || (a < 0 && b < 0 && c >= 0)
+}
+
+// MulOverflows multiplies a and b.
+// It returns the product and whether it overflowed an int, in which case the product has wrapped.
+func MulOverflows(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, false
+	}
+	c := a * b
+	if (a == -1 && b == math.MinInt) || (b == -1 && a == math.MinInt) {
+		return c, true
+	}
+	return c, c/b != a
+}
+
+// AddChecked adds a and b.
+// It will panic, naming both operands, if the sum overflows an int.
+func AddChecked(a, b int) int {
+	c, overflow := AddOverflows(a, b)
+	if overflow {
+		panic(fmt.Sprintf("AddChecked: %d + %d overflows int", a, b))
+	}
+	return c
+}
+
+// MulChecked multiplies a and b.
+// It will panic, naming both operands, if the product overflows an int.
+func MulChecked(a, b int) int {
+	c, overflow := MulOverflows(a, b)
+	if overflow {
+		panic(fmt.Sprintf("MulChecked: %d * %d overflows int", a, b))
+	}
+	return c
+}
+
+// Pow returns an int representing n to the m power, using exponentiation by squaring.
+// Pow(n, 0) returns 1 for every n, including 0.
+// It will panic if m is negative, since the result would not be an integer, or if the result overflows an int.
+func Pow(n, m int) int {
+	if m < 0 {
+		panic(fmt.Sprintf("Pow: negative exponent %d", m))
+	}
+	result := 1
+	for m > 0 {
+		if m&1 == 1 {
+			result = MulChecked(result, n)
+		}
+		m >>= 1
+		if m > 0 {
+			n = MulChecked(n, n)
+		}
+	}
+	return result
+}
+
+// mulMod returns a*b mod m for a and b in [0, m) without overflowing the intermediate product.
+func mulMod(a, b, m int) int {
+	hi, lo := bits.Mul64(uint64(a), uint64(b))
+	return int(bits.Rem64(hi, lo, uint64(m)))
+}
+
+// PowMod returns base to the exp power modulo mod, using square-and-multiply with
+// every intermediate product reduced so nothing overflows.
+// A negative base is normalized into [0, mod) first, and the result is always in [0, mod).
+// It will panic if exp is negative or mod is not positive.
+func PowMod(base, exp, mod int) int {
+	if exp < 0 {
+		panic(fmt.Sprintf("PowMod: negative exponent %d", exp))
+	}
+	if mod <= 0 {
+		panic(fmt.Sprintf("PowMod: modulus must be positive, got %d", mod))
+	}
+	base = ((base % mod) + mod) % mod
+	result := 1 % mod
+	for exp > 0 {
+		if exp&1 == 1 {
+			result = mulMod(result, base, mod)
+		}
+		base = mulMod(base, base, mod)
+		exp >>= 1
+	}
+	return result
+}
+
+// GCD returns the greatest common divisor of a and b using Euclid's algorithm.
+// The result is always non-negative, and GCD(0, 0) returns 0.
+// It works on the magnitudes as unsigned values, so math.MinInt is accepted, but it will panic
+// if the result is 2^63, which happens only for GCD(math.MinInt, 0) and GCD(math.MinInt, math.MinInt).
+func GCD(a, b int) int {
+	g := gcdUint(absUint(a), absUint(b))
+	if g > math.MaxInt {
+		panic(fmt.Sprintf("GCD: gcd(%d, %d) = 2^63 overflows int", a, b))
+	}
+	return int(g)
+}
+
+// absUint returns |x| as a uint, which holds the magnitude of every int including math.MinInt.
+func absUint(x int) uint {
+	if x < 0 {
+		return -uint(x)
+	}
+	return uint(x)
+}
+
+// gcdUint returns the greatest common divisor of a and b.
+func gcdUint(a, b uint) uint {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// LCM returns the least common multiple of a and b, computed as a/gcd*b to reduce the risk of overflow.
+// The result is always non-negative, and LCM returns 0 if either number is 0.
+// It will panic if the result overflows an int, which is always the case when either number is math.MinInt.
+func LCM(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	x, y := absUint(a), absUint(b)
+	hi, l := bits.Mul(x/gcdUint(x, y), y)
+	if hi != 0 || l > math.MaxInt {
+		panic(fmt.Sprintf("LCM: lcm(%d, %d) overflows int", a, b))
+	}
+	return int(l)
+}
+
+// GCDAll returns the greatest common divisor of all the given numbers.
+// A slice can be passed as GCDAll(nums...). GCDAll() returns 0, the identity for GCD.
+func GCDAll(nums ...int) (result int) {
+	for _, n := range nums {
+		result = GCD(result, n)
+	}
+	return
+}
+
+// LCMAll returns the least common multiple of all the given numbers.
+// A slice can be passed as LCMAll(nums...). LCMAll() returns 1, the identity for LCM.
+// It will panic if the result overflows an int at any step.
+func LCMAll(nums ...int) int {
+	result := 1
+	for _, n := range nums {
+		result = LCM(result, n)
+	}
+	return result
+}
+
+// ExtendedGCD runs the extended Euclidean algorithm on a and b.
+// It returns g = gcd(a, b), which is always non-negative, along with Bézout coefficients x and y such that a*x + b*y = g.
+func ExtendedGCD(a, b int) (g, x, y int) {
+	oldR, r := a, b
+	oldS, s := 1, 0
+	oldT, t := 0, 1
+	for r != 0 {
+		q := oldR / r
+		oldR, r = r, oldR-q*r
+		oldS, s = s, oldS-q*s
+		oldT, t = t, oldT-q*t
+	}
+	if oldR < 0 {
+		return -oldR, -oldS, -oldT
+	}
+	return oldR, oldS, oldT
+}
+
+// ModInverse finds the multiplicative inverse of a modulo m using the extended Euclidean algorithm.
+// A negative a is normalized into [0, m) first.
+// It returns the inverse in [0, m), or an error if m is not positive or gcd(a, m) is not 1.
+func ModInverse(a, m int) (int, error) {
+	if m <= 0 {
+		return 0, fmt.Errorf("ModInverse: modulus must be positive, got %d", m)
+	}
+	g, x, _ := ExtendedGCD(Mod(a, m), m)
+	if g != 1 {
+		return 0, fmt.Errorf("ModInverse: %d has no inverse modulo %d (gcd is %d)", a, m, g)
+	}
+	return Mod(x, m), nil
+}
+
+// DivMod divides a by b modulo m, by multiplying a by the modular inverse of b.
+// Negative a and b are normalized into [0, m) first, and the result is always in [0, m).
+// It will panic if m is not positive or b has no inverse modulo m.
+func DivMod(a, b, m int) int {
+	inv := Must(ModInverse(b, m))
+	return mulMod(Mod(a, m), inv, m)
+}
+
+// CRT solves the system of congruences x ≡ remainders[i] (mod moduli[i]) using the Chinese Remainder Theorem.
+// The moduli don't need to be pairwise coprime. Intermediate products are reduced so they can't overflow.
+// It returns the smallest non-negative solution and the combined modulus (the LCM of the moduli),
+// or an error if the inputs are invalid, the system has no solution, or the combined modulus overflows an int.
+func CRT(remainders, moduli []int) (int, int, error) {
+	if len(remainders) != len(moduli) {
+		return 0, 0, fmt.Errorf("CRT: %d remainders but %d moduli", len(remainders), len(moduli))
+	}
+	x, m := 0, 1
+	for i, mi := range moduli {
+		if mi <= 0 {
+			return 0, 0, fmt.Errorf("CRT: modulus at index %d must be positive, got %d", i, mi)
+		}
+		ri := Mod(remainders[i], mi)
+		// Find t such that x + m*t ≡ ri (mod mi).
+		g, inv, _ := ExtendedGCD(m, mi)
+		diff := ri - x%mi
+		if diff%g != 0 {
+			return 0, 0, fmt.Errorf("CRT: x ≡ %d (mod %d) is inconsistent with the previous congruences", remainders[i], mi)
+		}
+		step := mi / g
+		t := mulMod(Mod(diff/g, step), Mod(inv, step), step)
+		lcm, overflow := MulOverflows(m, step)
+		if overflow {
+			return 0, 0, fmt.Errorf("CRT: combined modulus overflows int at index %d", i)
+		}
+		x, m = x+m*t, lcm
+	}
+	return x, m, nil
+}
+
+// Factorial returns n!, with 0! = 1.
+// It will panic if n is negative or the result overflows an int (n > 20 with a 64-bit int).
+func Factorial(n int) int {
+	if n < 0 {
+		panic(fmt.Sprintf("Factorial: n must be non-negative, got %d", n))
+	}
+	result := 1
+	for i := 2; i <= n; i++ {
+		result = MulChecked(result, i)
+	}
+	return result
+}
+
+// Binomial returns the number of ways to choose k items from n, computed multiplicatively
+// rather than from factorials so results like Binomial(60, 30) don't overflow along the way.
+// It will panic if n or k is negative, k is greater than n, or the result overflows an int.
+func Binomial(n, k int) int {
+	if n < 0 || k < 0 || k > n {
+		panic(fmt.Sprintf("Binomial: invalid arguments n=%d, k=%d", n, k))
+	}
+	k = min(k, n-k)
+	result := 1
+	for i := 1; i <= k; i++ {
+		// result * (n-k+i) is divisible by i; dividing out the shared factor first keeps it small.
+		g := GCD(result, i)
+		result = MulChecked(result/g, (n-k+i)/(i/g))
+	}
+	return result
+}
+
+// PermutationCount returns the number of ordered arrangements of k items chosen from n, n!/(n-k)!.
+// It will panic if n or k is negative, k is greater than n, or the result overflows an int.
+func PermutationCount(n, k int) int {
+	if n < 0 || k < 0 || k > n {
+		panic(fmt.Sprintf("PermutationCount: invalid arguments n=%d, k=%d", n, k))
+	}
+	result := 1
+	for i := n - k + 1; i <= n; i++ {
+		result = MulChecked(result, i)
+	}
+	return result
+}
+
+// triangular computes n(n+1)/2 for n >= 0, halving the even factor first.
+// It returns the result and whether it overflowed an int.
+func triangular(n int) (int, bool) {
+	a, b := n, n+1
+	if a%2 == 0 {
+		a /= 2
+	} else {
+		b /= 2
+	}
+	return MulOverflows(a, b)
+}
+
+// Triangular returns the nth triangular number, 1 + 2 + ... + n = n(n+1)/2.
+// It will panic if n is negative or the result overflows an int.
+func Triangular(n int) int {
+	if n < 0 {
+		panic(fmt.Sprintf("Triangular: n must be non-negative, got %d", n))
+	}
+	t, overflow := triangular(n)
+	if overflow {
+		panic(fmt.Sprintf("Triangular: T(%d) overflows int", n))
+	}
+	return t
+}
+
+// SumRange returns the sum of every int from lo to hi inclusive, or 0 if lo is greater than hi.
+// It will panic if the result overflows an int, but not if only an intermediate step would.
+func SumRange(lo, hi int) int {
+	overflows := func() {
+		panic(fmt.Sprintf("SumRange: sum of %d to %d overflows int", lo, hi))
+	}
+	switch {
+	case lo > hi:
+		return 0
+	case lo < 0 && hi >= 0:
+		// Cancel the pairs -k and k on either side of 0, leaving a range on one side of it.
+		a := -(lo + 1) // lo is -a-1, and unlike -lo this can't overflow.
+		if hi <= a {
+			return SumRange(lo, -hi-1)
+		}
+		if hi == a+1 {
+			return 0
+		}
+		return SumRange(a+2, hi)
+	case hi < 0 && lo == math.MinInt:
+		sum, overflow := AddOverflows(math.MinInt, SumRange(lo+1, hi))
+		if overflow {
+			overflows()
+		}
+		return sum
+	case hi < 0:
+		return -SumRange(-hi, -lo)
+	}
+	// Every term of lo*(n+1) + T(n) is non-negative and no larger than the sum, so nothing overflows early.
+	n := hi - lo
+	if n == math.MaxInt {
+		overflows()
+	}
+	t, overflow := triangular(n)
+	base, overflow2 := MulOverflows(lo, n+1)
+	sum, overflow3 := AddOverflows(base, t)
+	if overflow || overflow2 || overflow3 {
+		overflows()
+	}
+	return sum
+}
+
+// InverseTriangular checks if t is a triangular number.
+// It returns n and true if t is the nth triangular number, or the largest n with T(n) < t and false otherwise.
+// For negative t it returns 0 and false.
+func InverseTriangular(t int) (n int, ok bool) {
+	if t < 0 {
+		return 0, false
+	}
+	exceeds := func(n int) bool {
+		tn, overflow := triangular(n)
+		return overflow || tn > t
+	}
+	n = int(math.Sqrt(2 * float64(t)))
+	for n > 0 && exceeds(n) {
+		n--
+	}
+	for !exceeds(n + 1) {
+		n++
+	}
+	tn, _ := triangular(n)
+	if tn != t {
+		return n, false
+	}
+	return n, true
+}
+
+// isqrt returns the floor of the square root of a non-negative int.
+func isqrt(n int) int {
+	r := int(math.Sqrt(float64(n)))
+	for r*r > n {
+		r--
+	}
+	for (r+1)*(r+1) <= n {
+		r++
+	}
+	return r
+}
+
+// IsPrime checks if n is a prime number, using trial division for small n and
+// a deterministic Miller-Rabin test for anything that fits in an int.
+// It returns a bool.
+func IsPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	if n < 1<<20 {
+		if n%2 == 0 {
+			return n == 2
+		}
+		limit := isqrt(n)
+		for i := 3; i <= limit; i += 2 {
+			if n%i == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	d, r := n-1, 0
+	for d%2 == 0 {
+		d /= 2
+		r++
+	}
+	// The first twelve primes as bases are proven sufficient for every n below about 3.18e23, which covers all 64-bit n.
+	for _, a := range []int{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37} {
+		if n%a == 0 {
+			return false
+		}
+		x := PowMod(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		composite := true
+		for i := 1; i < r && composite; i++ {
+			x = mulMod(x, x, n)
+			composite = x != n-1
+		}
+		if composite {
+			return false
+		}
+	}
+	return true
+}
+
+// PrimesUpTo finds every prime less than or equal to n using a sieve of Eratosthenes.
+// The sieve only tracks odd numbers, one bit each, so sieving to 1e8 needs about 6MB.
+// It returns a slice of ints in ascending order.
+func PrimesUpTo(n int) (primes []int) {
+	if n < 2 {
+		return
+	}
+	primes = append(primes, 2)
+	// Bit i represents the odd number 2i+1.
+	composite := NewBitSet(n/2 + 1)
+	for i := 1; 2*i+1 <= n; i++ {
+		if composite.Get(i) {
+			continue
+		}
+		p := 2*i + 1
+		primes = append(primes, p)
+		if p > n/p {
+			continue
+		}
+		for j := p * p; j <= n; j += 2 * p {
+			composite.Set(j / 2)
+		}
+	}
+	return
+}
+
+// Primes generates every prime in ascending order using an incremental sieve,
+// for when the upper bound isn't known in advance. The sequence is unbounded, so
+// callers must break out of the loop.
+// It returns an iter.Seq of ints.
+func Primes() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		if !yield(2) {
+			return
+		}
+		// next maps each upcoming odd composite to the step (twice its prime factor) that reached it.
+		next := make(map[int]int)
+		for n := 3; ; n += 2 {
+			step, ok := next[n]
+			if !ok {
+				next[n*n] = 2 * n
+				if !yield(n) {
+					return
+				}
+				continue
+			}
+			delete(next, n)
+			m := n + step
+			for next[m] != 0 {
+				m += step
+			}
+			next[m] = step
+		}
+	}
+}
+
+// Factorize finds the prime factors of n along with their multiplicities.
+// Factorize(1) returns an empty map.
+// It will panic if n is less than 1.
+// It returns a map of prime factor to exponent.
+func Factorize(n int) map[int]int {
+	if n < 1 {
+		panic(fmt.Sprintf("Factorize: n must be positive, got %d", n))
+	}
+	factors := make(map[int]int)
+	for n%2 == 0 {
+		factors[2]++
+		n /= 2
+	}
+	for i := 3; i*i <= n; i += 2 {
+		for n%i == 0 {
+			factors[i]++
+			n /= i
+		}
+	}
+	if n > 1 {
+		factors[n]++
+	}
+	return factors
+}
+
+// FactorizeAll finds the prime factorization of every number from 0 to upTo at once,
+// using a smallest-prime-factor sieve so each factorization takes only O(log n) divisions.
+// Entry 0 is nil and entry 1 is an empty map.
+// It returns a slice of maps of prime factor to exponent, indexed by n.
+func FactorizeAll(upTo int) []map[int]int {
+	if upTo < 0 {
+		return nil
+	}
+	spf := make([]int, upTo+1)
+	for i := 2; i <= upTo; i++ {
+		if spf[i] != 0 {
+			continue
+		}
+		for j := i; j <= upTo; j += i {
+			if spf[j] == 0 {
+				spf[j] = i
+			}
+		}
+	}
+	all := make([]map[int]int, upTo+1)
+	for n := 1; n <= upTo; n++ {
+		factors := make(map[int]int)
+		for m := n; m > 1; m /= spf[m] {
+			factors[spf[m]]++
+		}
+		all[n] = factors
+	}
+	return all
+}
+
+// Divisors finds every positive divisor of n.
+// It will panic if n is less than 1.
+// It returns a slice of ints in ascending order.
+func Divisors(n int) []int {
+	if n < 1 {
+		panic(fmt.Sprintf("Divisors: n must be positive, got %d", n))
+	}
+	small, large := make([]int, 0), make([]int, 0)
+	for i := 1; i*i <= n; i++ {
+		if n%i == 0 {
+			small = append(small, i)
+			if i != n/i {
+				large = append(large, n/i)
+			}
+		}
+	}
+	for i := len(large) - 1; i >= 0; i-- {
+		small = append(small, large[i])
+	}
+	return small
+}
+
+// CountDivisors counts the positive divisors of n from its prime factorization, without listing them.
+// It will panic if n is less than 1.
+// It returns an int.
+func CountDivisors(n int) int {
+	count := 1
+	for _, e := range Factorize(n) {
+		count *= e + 1
+	}
+	return count
+}
+
+// SumDivisors adds up the positive divisors of n, including n itself, from its prime factorization
+// rather than by listing them.
+// It will panic if n is less than 1 or the sum overflows an int.
+// It returns an int.
+func SumDivisors(n int) int {
+	sum := 1
+	for p, e := range Factorize(n) {
+		// 1 + p + p^2 + ... + p^e
+		term, power := 1, 1
+		for i := 0; i < e; i++ {
+			power = MulChecked(power, p)
+			term = AddChecked(term, power)
+		}
+		sum = MulChecked(sum, term)
+	}
+	return sum
+}
+
+// Digits splits n into its base-10 digits, most significant first.
+// Negative numbers are split using their absolute value, and Digits(0) returns [0].
+// It returns a slice of ints.
+func Digits(n int) []int {
+	digits := make([]int, NumDigits(n))
+	for i := len(digits) - 1; i >= 0; i-- {
+		digits[i] = Abs(n % 10)
+		n /= 10
+	}
+	return digits
+}
+
+// FromDigits reassembles a slice of base-10 digits, most significant first, into an int.
+// It will panic if any digit is outside 0-9 or the result overflows an int.
+// It returns an int.
+func FromDigits(digits []int) (num int) {
+	for i, d := range digits {
+		if d < 0 || d > 9 {
+			panic(fmt.Sprintf("FromDigits: invalid digit %d at index %d", d, i))
+		}
+		if num > (math.MaxInt-d)/10 {
+			panic(fmt.Sprintf("FromDigits: %v overflows int", digits))
+		}
+		num = num*10 + d
+	}
+	return
+}
+
+// NumDigits counts the base-10 digits of n without converting it to a string.
+// The sign of a negative number is not counted, and NumDigits(0) returns 1.
+// It returns an int.
+func NumDigits(n int) int {
+	count := 1
+	for n /= 10; n != 0; n /= 10 {
+		count++
+	}
+	return count
+}
+
+// ConcatInts joins the decimal digits of a and b into one number, so ConcatInts(12, 345) returns 12345,
+// using integer math rather than string conversion.
+// It will panic if either number is negative or the result overflows an int.
+// It returns an int.
+func ConcatInts(a, b int) int {
+	if a < 0 || b < 0 {
+		panic(fmt.Sprintf("ConcatInts: numbers must be non-negative, got %d and %d", a, b))
+	}
+	if a == 0 {
+		return b
+	}
+	digits := NumDigits(b)
+	if digits >= NumDigits(math.MaxInt) {
+		panic(fmt.Sprintf("ConcatInts: %d || %d overflows int", a, b))
+	}
+	shift := 10
+	for i := 1; i < digits; i++ {
+		shift *= 10
+	}
+	c, overflow := MulOverflows(a, shift)
+	if !overflow {
+		c, overflow = AddOverflows(c, b)
+	}
+	if overflow {
+		panic(fmt.Sprintf("ConcatInts: %d || %d overflows int", a, b))
+	}
+	return c
+}
+
+// Matrices
+
+// A type representing a matrix of ints, stored as a slice of rows.
+type Matrix [][]int
+
+// NewMatrix creates a matrix of zeros with the given number of rows and columns.
+// It returns a Matrix.
+func NewMatrix(rows, cols int) Matrix {
+	m := make(Matrix, rows)
+	for i := range m {
+		m[i] = make([]int, cols)
+	}
+	return m
+}
+
+// Identity creates the n by n identity matrix.
+// It returns a Matrix.
+func Identity(n int) Matrix {
+	m := NewMatrix(n, n)
+	for i := range m {
+		m[i][i] = 1
+	}
+	return m
+}
+
+// MatMul multiplies two matrices. Entries are not checked for overflow; use MatMulMod to keep them bounded.
+// It will panic if the number of columns of a doesn't match the number of rows of b.
+// It returns a new Matrix.
+func MatMul(a, b Matrix) Matrix {
+	return matMul(a, b, func(x, y int) int { return x * y }, func(x, y int) int { return x + y })
+}
+
+// MatMulMod multiplies two matrices with every entry reduced modulo mod, so nothing overflows.
+// Entries of the result are in [0, mod).
+// It will panic if the dimensions don't match or mod is not positive.
+// It returns a new Matrix.
+func MatMulMod(a, b Matrix, mod int) Matrix {
+	if mod <= 0 {
+		panic(fmt.Sprintf("MatMulMod: modulus must be positive, got %d", mod))
+	}
+	return matMul(a, b,
+		func(x, y int) int { return mulMod(Mod(x, mod), Mod(y, mod), mod) },
+		func(x, y int) int { return (x + y) % mod })
+}
+
+// matrixDims counts the rows and columns of a matrix, naming caller in the panic if its rows differ in length.
+func matrixDims(m Matrix, caller string) (rows, cols int) {
+	if len(m) == 0 {
+		return 0, 0
+	}
+	for i, row := range m {
+		if len(row) != len(m[0]) {
+			panic(fmt.Sprintf("%s: row %d has %d columns, expected %d", caller, i, len(row), len(m[0])))
+		}
+	}
+	return len(m), len(m[0])
+}
+
+// matMul multiplies two matrices using the given element multiplication and addition.
+func matMul(a, b Matrix, mul, add func(x, y int) int) Matrix {
+	aRows, aCols := matrixDims(a, "MatMul")
+	bRows, cols := matrixDims(b, "MatMul")
+	if aRows > 0 && aCols != bRows {
+		panic(fmt.Sprintf("MatMul: cannot multiply %dx%d by %dx%d matrix", aRows, aCols, bRows, cols))
+	}
+	result := NewMatrix(len(a), cols)
+	for i := range a {
+		for k := range b {
+			for j := 0; j < cols; j++ {
+				result[i][j] = add(result[i][j], mul(a[i][k], b[k][j]))
+			}
+		}
+	}
+	return result
+}
+
+// MatPow raises a square matrix to the kth power using square-and-multiply, so Fibonacci numbers
+// come from MatPow([[1 1] [1 0]], n). Entries are not checked for overflow; use MatPowMod to keep them bounded.
+// It will panic if m is not square or k is negative.
+// It returns a new Matrix.
+func MatPow(m Matrix, k int) Matrix {
+	return matPow(m, k, MatMul)
+}
+
+// MatPowMod raises a square matrix to the kth power with every entry reduced modulo mod.
+// Entries of the result are in [0, mod).
+// It will panic if m is not square, k is negative, or mod is not positive.
+// It returns a new Matrix.
+func MatPowMod(m Matrix, k, mod int) Matrix {
+	return matPow(m, k, func(a, b Matrix) Matrix { return MatMulMod(a, b, mod) })
+}
+
+// matPow raises a square matrix to the kth power using the given matrix multiplication.
+func matPow(m Matrix, k int, mul func(a, b Matrix) Matrix) Matrix {
+	if rows, cols := matrixDims(m, "MatPow"); rows != cols {
+		panic(fmt.Sprintf("MatPow: %dx%d matrix is not square", rows, cols))
+	}
+	if k < 0 {
+		panic(fmt.Sprintf("MatPow: negative exponent %d", k))
+	}
+	// Multiplying identities, rather than using one directly, reduces its entries in the modular case.
+	result := mul(Identity(len(m)), Identity(len(m)))
+	for k > 0 {
+		if k&1 == 1 {
+			result = mul(result, m)
+		}
+		k >>= 1
+		if k > 0 {
+			m = mul(m, m)
+		}
+	}
+	return result
+}
+
+// Array Utils
+// Shamelessly copied from https://go.dev/wiki/SliceTricks
+
+// Cut removes a range of elements from a slice of type T
+// It returns a new slice of type T.
+func Cut[T any](slice []T, start, end int) []T {
+	copy(slice[start:], slice[end:])
+	for k, n := len(slice)-end+start, len(slice); k < n; k++ {
+		slice[k] = *new(T)
+	}
+	return slice[:len(slice)-end+start]
+}
+
+// Delete removes an element at a given index from a slice of type T,
+// while preserving the original order of the slice.
+// It returns a new slice of type T.
+func Delete[T any](slice []T, index int) []T {
+	copy(slice[index:], slice[index+1:])
+	slice[len(slice)-1] = *new(T)
+	return slice[:len(slice)-1]
+}
+
+// Insert adds an element at a given index to a slice of type T
+// It returns a new slice of type T.
+func Insert[T any](slice []T, element T, index int) []T {
+	slice = append(slice, *new(T))
+	copy(slice[index+1:], slice[index:])
+	slice[index] = element
+	return slice
+}
+
+// Rotate shifts the elements of a slice of type T cyclically in place.
+// A positive n rotates left, so Rotate([1 2 3 4], 1) gives [2 3 4 1], and a negative n rotates right.
+// Values of n larger than the slice length wrap around.
+func Rotate[T any](slice []T, n int) {
+	if len(slice) == 0 {
+		return
+	}
+	n = ((n % len(slice)) + len(slice)) % len(slice)
+	reverse := func(s []T) {
+		for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+			s[i], s[j] = s[j], s[i]
+		}
+	}
+	reverse(slice[:n])
+	reverse(slice[n:])
+	reverse(slice)
+}
+
+// Rotated shifts the elements of a slice of type T cyclically like Rotate, leaving the original untouched.
+// It returns a new slice of type T.
+func Rotated[T any](slice []T, n int) []T {
+	rotated := append([]T(nil), slice...)
+	Rotate(rotated, n)
+	return rotated
+}
+
+// Sort sorts a slice of an ordered type T in ascending order, in place.
+func Sort[T cmp.Ordered](slice []T) {
+	slices.Sort(slice)
+}
+
+// Sorted sorts a copy of a slice of an ordered type T in ascending order, leaving the original untouched.
+// It returns a new slice of type T.
+func Sorted[T cmp.Ordered](slice []T) []T {
+	sorted := append([]T(nil), slice...)
+	slices.Sort(sorted)
+	return sorted
+}
+
+// SortedFunc sorts a copy of a slice of type T using less, leaving the original untouched.
+// The sort is stable, so equal elements keep their original order.
+// It returns a new slice of type T.
+func SortedFunc[T any](slice []T, less func(a, b T) bool) []T {
+	sorted := append([]T(nil), slice...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	return sorted
+}
+
+// SliceEqual checks if two slices of type T have the same length and elements in the same order.
+// It returns a bool.
+func SliceEqual[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GroupBy buckets the elements of a slice of type T by the key returned for each one,
+// keeping elements within each group in their original order.
+// It returns a map of key to slice of type T.
+func GroupBy[T any, K comparable](slice []T, key func(T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for _, element := range slice {
+		k := key(element)
+		groups[k] = append(groups[k], element)
+	}
+	return groups
+}
+
+// Partition splits a slice of type T into the elements that satisfy pred and those that don't,
+// keeping both in their original order.
+// It returns two new slices of type T.
+func Partition[T any](slice []T, pred func(T) bool) (yes, no []T) {
+	for _, element := range slice {
+		if pred(element) {
+			yes = append(yes, element)
+		} else {
+			no = append(no, element)
+		}
+	}
+	return
+}
+
+// A type representing a pair of values of types A and B.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Zip pairs up the elements of two slices by index, truncating to the shorter slice.
+// It returns a slice of Pairs.
+func Zip[A, B any](a []A, b []B) []Pair[A, B] {
+	pairs := make([]Pair[A, B], min(len(a), len(b)))
+	for i := range pairs {
+		pairs[i] = Pair[A, B]{a[i], b[i]}
+	}
+	return pairs
+}
+
+// Enumerate pairs each element of a slice of type T with its index.
+// It returns a slice of Pairs of index and element.
+func Enumerate[T any](slice []T) []Pair[int, T] {
+	pairs := make([]Pair[int, T], len(slice))
+	for i, element := range slice {
+		pairs[i] = Pair[int, T]{i, element}
+	}
+	return pairs
+}
+
+// Pairs collects every unordered pair of elements of a slice of type T,
+// taking (slice[i], slice[j]) exactly once for each i < j.
+// It returns a slice of Pairs.
+func Pairs[T any](slice []T) []Pair[T, T] {
+	pairs := make([]Pair[T, T], 0, len(slice)*(len(slice)-1)/2)
+	PairsFunc(slice, func(a, b T) {
+		pairs = append(pairs, Pair[T, T]{a, b})
+	})
+	return pairs
+}
+
+// PairsFunc calls visit with every unordered pair of elements of a slice of type T,
+// (slice[i], slice[j]) exactly once for each i < j, without building a slice of them.
+func PairsFunc[T any](slice []T, visit func(a, b T)) {
+	for i := range slice {
+		for j := i + 1; j < len(slice); j++ {
+			visit(slice[i], slice[j])
+		}
+	}
+}
+
+// A type representing a run of Count consecutive copies of Value.
+type Run[T any] struct {
+	Value T
+	Count int
+}
+
+// RunLengthEncode groups consecutive equal elements of a slice of type T into runs.
+// It returns a slice of Runs in order.
+func RunLengthEncode[T comparable](slice []T) []Run[T] {
+	runs := make([]Run[T], 0)
+	for _, element := range slice {
+		if n := len(runs); n > 0 && runs[n-1].Value == element {
+			runs[n-1].Count++
+			continue
+		}
+		runs = append(runs, Run[T]{Value: element, Count: 1})
+	}
+	return runs
+}
+
+// RunLengthDecode expands runs back into the slice of type T they were encoded from.
+// It returns a new slice of type T.
+func RunLengthDecode[T any](runs []Run[T]) []T {
+	slice := make([]T, 0)
+	for _, run := range runs {
+		for i := 0; i < run.Count; i++ {
+			slice = append(slice, run.Value)
+		}
+	}
+	return slice
+}
+
+// LookAndSay computes the next term of the look-and-say sequence by reading out each run of
+// characters as its length followed by the character, so "1211" becomes "111221".
+// It returns a string.
+func LookAndSay(s string) string {
+	var sb strings.Builder
+	for _, run := range RunLengthEncode([]rune(s)) {
+		sb.WriteString(strconv.Itoa(run.Count))
+		sb.WriteRune(run.Value)
+	}
+	return sb.String()
+}
+
+// A type representing a slice of type T.
+type Stack[T any] []T
+
+// Push adds the given elements to the end of a stack of type T.
+func (s *Stack[T]) Push(elements ...T) {
+	*s = append(*s, elements...)
+}
+
+// Pop removes an element from the end of a stack of type T.
+// It will panic if the stack is empty.
+// It returns the removed element.
+func (s *Stack[T]) Pop() T {
+	if len(*s) == 0 {
+		panic("Stack.Pop: stack is empty")
+	}
+	last := (*s)[len(*s)-1]
+	*s = (*s)[:len(*s)-1]
+	return last
+}
+
+// Unshift adds an element to the beginning of a stack of type T.
+func (s *Stack[T]) Unshift(element T) {
+	*s = append(*s, element)
+	copy((*s)[1:], (*s)[:len(*s)-1])
+	(*s)[0] = element
+}
+
+// Shift removes an element from the beginning of a stack of type T.
+// It will panic if the stack is empty.
+// It returns the removed element.
+func (s *Stack[T]) Shift() T {
+	if len(*s) == 0 {
+		panic("Stack.Shift: stack is empty")
+	}
+	first := (*s)[0]
+	*s = (*s)[1:]
+	return first
+}
+
+// TryPop removes an element from the end of a stack of type T, if there is one.
+// It returns the removed element and true, or the zero value and false if the stack is empty.
+func (s *Stack[T]) TryPop() (T, bool) {
+	if len(*s) == 0 {
+		return *new(T), false
+	}
+	return s.Pop(), true
+}
+
+// Peek looks at the element at the end of a stack of type T without removing it.
+// It returns the element and true, or the zero value and false if the stack is empty.
+func (s Stack[T]) Peek() (T, bool) {
+	if len(s) == 0 {
+		return *new(T), false
+	}
+	return s[len(s)-1], true
+}
+
+// Len returns the number of elements in a stack of type T.
+func (s Stack[T]) Len() int {
+	return len(s)
+}
+
+// IsEmpty checks if a stack of type T has no elements.
+// It returns a bool.
+func (s Stack[T]) IsEmpty() bool {
+	return len(s) == 0
+}
+
+// Clear removes every element from a stack of type T.
+func (s *Stack[T]) Clear() {
+	*s = (*s)[:0]
+}
+
+// A type representing a first-in, first-out queue of type T.
+// Dequeued slots are zeroed and the queue compacts itself in place, so every operation is amortized O(1).
+// The zero value is an empty queue ready to use.
+type Queue[T any] struct {
+	items []T
+	head  int
+}
+
+// Enqueue adds the given elements to the back of a queue.
+func (q *Queue[T]) Enqueue(elements ...T) {
+	q.items = append(q.items, elements...)
+}
+
+// Dequeue removes an element from the front of a queue.
+// It returns the removed element and true, or the zero value and false if the queue is empty.
+func (q *Queue[T]) Dequeue() (T, bool) {
+	if q.head == len(q.items) {
+		return *new(T), false
+	}
+	element := q.items[q.head]
+	q.items[q.head] = *new(T)
+	q.head++
+	if q.head == len(q.items) {
+		q.items = q.items[:0]
+		q.head = 0
+	} else if 2*q.head >= len(q.items) {
+		n := copy(q.items, q.items[q.head:])
+		clear(q.items[n:])
+		q.items = q.items[:n]
+		q.head = 0
+	}
+	return element, true
+}
+
+// Peek looks at the element at the front of a queue without removing it.
+// It returns the element and true, or the zero value and false if the queue is empty.
+func (q *Queue[T]) Peek() (T, bool) {
+	if q.head == len(q.items) {
+		return *new(T), false
+	}
+	return q.items[q.head], true
+}
+
+// Len returns the number of elements in a queue.
+func (q *Queue[T]) Len() int {
+	return len(q.items) - q.head
+}
+
+// IsEmpty checks if a queue has no elements.
+// It returns a bool.
+func (q *Queue[T]) IsEmpty() bool {
+	return q.Len() == 0
+}
+
+// A type representing a double-ended queue of type T, backed by a ring buffer.
+// The zero value is an empty deque ready to use.
+type Deque[T any] struct {
+	buf  []T
+	head int
+	size int
+}
+
+// grow doubles the capacity of a deque, unwrapping its elements to the start of the new buffer.
+func (d *Deque[T]) grow() {
+	buf := make([]T, max(1, 2*len(d.buf)))
+	for i := 0; i < d.size; i++ {
+		buf[i] = d.buf[(d.head+i)%len(d.buf)]
+	}
+	d.buf = buf
+	d.head = 0
+}
+
+// Len returns the number of elements in a deque.
+func (d *Deque[T]) Len() int {
+	return d.size
+}
+
+// PushFront adds an element to the front of a deque.
+func (d *Deque[T]) PushFront(element T) {
+	if d.size == len(d.buf) {
+		d.grow()
+	}
+	d.head = (d.head - 1 + len(d.buf)) % len(d.buf)
+	d.buf[d.head] = element
+	d.size++
+}
+
+// PushBack adds an element to the back of a deque.
+func (d *Deque[T]) PushBack(element T) {
+	if d.size == len(d.buf) {
+		d.grow()
+	}
+	d.buf[(d.head+d.size)%len(d.buf)] = element
+	d.size++
+}
+
+// PopFront removes an element from the front of a deque.
+// It returns the removed element and true, or the zero value and false if the deque is empty.
+func (d *Deque[T]) PopFront() (T, bool) {
+	if d.size == 0 {
+		return *new(T), false
+	}
+	element := d.buf[d.head]
+	d.buf[d.head] = *new(T)
+	d.head = (d.head + 1) % len(d.buf)
+	d.size--
+	return element, true
+}
+
+// PopBack removes an element from the back of a deque.
+// It returns the removed element and true, or the zero value and false if the deque is empty.
+func (d *Deque[T]) PopBack() (T, bool) {
+	if d.size == 0 {
+		return *new(T), false
+	}
+	i := (d.head + d.size - 1) % len(d.buf)
+	element := d.buf[i]
+	d.buf[i] = *new(T)
+	d.size--
+	return element, true
+}
+
+// PeekFront returns the element at the front of a deque without removing it,
+// and false if the deque is empty.
+func (d *Deque[T]) PeekFront() (T, bool) {
+	if d.size == 0 {
+		return *new(T), false
+	}
+	return d.buf[d.head], true
+}
+
+// PeekBack returns the element at the back of a deque without removing it,
+// and false if the deque is empty.
+func (d *Deque[T]) PeekBack() (T, bool) {
+	if d.size == 0 {
+		return *new(T), false
+	}
+	return d.buf[(d.head+d.size-1)%len(d.buf)], true
+}
+
+// At reads the element i places from the front of a deque, so At(0) is the front and At(Len()-1) is the back.
+// It will panic if i is out of range.
+// It returns the element.
+func (d *Deque[T]) At(i int) T {
+	if i < 0 || i >= d.size {
+		panic(fmt.Sprintf("Deque.At: index %d out of range for length %d", i, d.size))
+	}
+	return d.buf[(d.head+i)%len(d.buf)]
+}
+
+// A type adapting a slice of type T and an ordering to heap.Interface.
+type priorityHeap[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+func (h *priorityHeap[T]) Len() int {
+	return len(h.items)
+}
+
+func (h *priorityHeap[T]) Less(i, j int) bool {
+	return h.less(h.items[i], h.items[j])
+}
+
+func (h *priorityHeap[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *priorityHeap[T]) Push(x any) {
+	h.items = append(h.items, x.(T))
+}
+
+func (h *priorityHeap[T]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items[len(h.items)-1] = *new(T)
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// A type representing a priority queue of type T, where the element for which less reports true against
+// every other element comes out first. Passing a < b gives a min-heap and a > b gives a max-heap.
+// Create one with NewPriorityQueue.
+type PriorityQueue[T any] struct {
+	h *priorityHeap[T]
+}
+
+// NewPriorityQueue creates an empty priority queue ordered by less.
+// It returns a pointer to the PriorityQueue.
+func NewPriorityQueue[T any](less func(a, b T) bool) *PriorityQueue[T] {
+	return &PriorityQueue[T]{h: &priorityHeap[T]{less: less}}
+}
+
+// Push adds the given elements to a priority queue.
+func (pq *PriorityQueue[T]) Push(elements ...T) {
+	for _, element := range elements {
+		heap.Push(pq.h, element)
+	}
+}
+
+// Pop removes the first element from a priority queue.
+// It returns the removed element and true, or the zero value and false if the queue is empty.
+func (pq *PriorityQueue[T]) Pop() (T, bool) {
+	if pq.h.Len() == 0 {
+		return *new(T), false
+	}
+	return heap.Pop(pq.h).(T), true
+}
+
+// Peek looks at the first element of a priority queue without removing it.
+// It returns the element and true, or the zero value and false if the queue is empty.
+func (pq *PriorityQueue[T]) Peek() (T, bool) {
+	if pq.h.Len() == 0 {
+		return *new(T), false
+	}
+	return pq.h.items[0], true
+}
+
+// Len returns the number of elements in a priority queue.
+func (pq *PriorityQueue[T]) Len() int {
+	return pq.h.Len()
+}
+
+// A type representing a key stored in an IndexedPriorityQueue along with its priority.
+type indexedEntry[K comparable, P any] struct {
+	key      K
+	priority P
+}
+
+// A type adapting a slice of entries to heap.Interface, tracking the position of each key as entries move.
+type indexedHeap[K comparable, P any] struct {
+	entries []indexedEntry[K, P]
+	index   map[K]int
+	less    func(a, b P) bool
+}
+
+func (h *indexedHeap[K, P]) Len() int {
+	return len(h.entries)
+}
+
+func (h *indexedHeap[K, P]) Less(i, j int) bool {
+	return h.less(h.entries[i].priority, h.entries[j].priority)
+}
+
+func (h *indexedHeap[K, P]) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.index[h.entries[i].key] = i
+	h.index[h.entries[j].key] = j
+}
+
+func (h *indexedHeap[K, P]) Push(x any) {
+	entry := x.(indexedEntry[K, P])
+	h.index[entry.key] = len(h.entries)
+	h.entries = append(h.entries, entry)
+}
+
+func (h *indexedHeap[K, P]) Pop() any {
+	last := h.entries[len(h.entries)-1]
+	h.entries[len(h.entries)-1] = indexedEntry[K, P]{}
+	h.entries = h.entries[:len(h.entries)-1]
+	delete(h.index, last.key)
+	return last
+}
+
+// A type representing a priority queue of distinct keys, each with a priority that can be changed while queued,
+// so Dijkstra and A* can decrease a node's distance in place. Create one with NewIndexedPriorityQueue.
+//
+// The alternative is the lazy approach with a plain PriorityQueue: push a node again whenever its distance
+// improves and skip stale entries as they are popped. That queue can grow to one entry per edge rather than
+// one per node, but it avoids the map lookups done on every swap here, so it is often as fast or faster.
+// Prefer this type when memory is tight, when duplicates would be expensive to skip, or when you need
+// Contains or Priority to ask about a key's current state.
+type IndexedPriorityQueue[K comparable, P any] struct {
+	h *indexedHeap[K, P]
+}
+
+// NewIndexedPriorityQueue creates an empty indexed priority queue, where PopMin removes the key whose priority
+// less reports as coming before all others. Passing a < b pops the smallest priority first.
+// It returns a pointer to the IndexedPriorityQueue.
+func NewIndexedPriorityQueue[K comparable, P any](less func(a, b P) bool) *IndexedPriorityQueue[K, P] {
+	return &IndexedPriorityQueue[K, P]{h: &indexedHeap[K, P]{index: make(map[K]int), less: less}}
+}
+
+// Insert adds a key with the given priority to an indexed priority queue.
+// It will panic if the key is already queued.
+func (pq *IndexedPriorityQueue[K, P]) Insert(key K, priority P) {
+	if pq.Contains(key) {
+		panic(fmt.Sprintf("IndexedPriorityQueue.Insert: key %v is already queued", key))
+	}
+	heap.Push(pq.h, indexedEntry[K, P]{key: key, priority: priority})
+}
+
+// UpdatePriority changes the priority of a key in an indexed priority queue, in either direction.
+// It will panic if the key is not queued.
+func (pq *IndexedPriorityQueue[K, P]) UpdatePriority(key K, priority P) {
+	i, ok := pq.h.index[key]
+	if !ok {
+		panic(fmt.Sprintf("IndexedPriorityQueue.UpdatePriority: key %v is not queued", key))
+	}
+	pq.h.entries[i].priority = priority
+	heap.Fix(pq.h, i)
+}
+
+// Contains checks if a key is in an indexed priority queue.
+// It returns a bool.
+func (pq *IndexedPriorityQueue[K, P]) Contains(key K) bool {
+	_, ok := pq.h.index[key]
+	return ok
+}
+
+// Priority looks up the current priority of a key in an indexed priority queue.
+// It returns the priority and true, or the zero value and false if the key is not queued.
+func (pq *IndexedPriorityQueue[K, P]) Priority(key K) (P, bool) {
+	i, ok := pq.h.index[key]
+	if !ok {
+		return *new(P), false
+	}
+	return pq.h.entries[i].priority, true
+}
+
+// PopMin removes the key with the first priority from an indexed priority queue.
+// It will panic if the queue is empty.
+// It returns the removed key and its priority.
+func (pq *IndexedPriorityQueue[K, P]) PopMin() (K, P) {
+	if pq.h.Len() == 0 {
+		panic("IndexedPriorityQueue.PopMin: queue is empty")
+	}
+	entry := heap.Pop(pq.h).(indexedEntry[K, P])
+	return entry.key, entry.priority
+}
+
+// Len returns the number of keys in an indexed priority queue.
+func (pq *IndexedPriorityQueue[K, P]) Len() int {
+	return pq.h.Len()
+}
+
+// Map Utils
+
+// CloneMap makes a shallow copy of a map: the new map can have keys added or removed without
+// affecting the original, but the values themselves are copied as-is, so pointers, slices and
+// maps stored as values still alias those in the original.
+// It returns a new map.
+func CloneMap[K comparable, V any](m map[K]V) map[K]V {
+	clone := make(map[K]V, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
+// Keys collects the keys of a map in no particular order.
+// It returns a slice of type K.
+func Keys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Values collects the values of a map in no particular order.
+// It returns a slice of type V.
+func Values[K comparable, V any](m map[K]V) []V {
+	values := make([]V, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+// SortedKeys collects the keys of a map in ascending order, for deterministic iteration and output.
+// It returns a slice of type K.
+func SortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := Keys(m)
+	slices.Sort(keys)
+	return keys
+}
+
+// Counters
+
+// A type representing a frequency count of items of type T.
+type Counter[T comparable] map[T]int
+
+// CountSlice counts the occurrences of every element in a slice of type T.
+// It returns a Counter of type T.
+func CountSlice[T comparable](slice []T) Counter[T] {
+	c := make(Counter[T])
+	for _, element := range slice {
+		c[element]++
+	}
+	return c
+}
+
+// Add increments the count of an item by one.
+func (c Counter[T]) Add(item T) {
+	c[item]++
+}
+
+// AddN increments the count of an item by n.
+func (c Counter[T]) AddN(item T, n int) {
+	c[item] += n
+}
+
+// Get returns the count of an item, which is 0 if it has never been added.
+func (c Counter[T]) Get(item T) int {
+	return c[item]
+}
+
+// Total returns the sum of all counts in a counter.
+func (c Counter[T]) Total() (total int) {
+	for _, n := range c {
+		total += n
+	}
+	return
+}
+
+// Most finds the most frequent item in a counter. Ties are broken arbitrarily.
+// It returns the item and its count, or the zero value and 0 if the counter is empty.
+func (c Counter[T]) Most() (item T, count int) {
+	first := true
+	for k, n := range c {
+		if first || n > count {
+			item, count, first = k, n, false
+		}
+	}
+	return
+}
+
+// Least finds the least frequent item in a counter. Ties are broken arbitrarily.
+// It returns the item and its count, or the zero value and 0 if the counter is empty.
+func (c Counter[T]) Least() (item T, count int) {
+	first := true
+	for k, n := range c {
+		if first || n < count {
+			item, count, first = k, n, false
+		}
+	}
+	return
+}
+
+// Merge adds every count in o to the matching count in c.
+func (c Counter[T]) Merge(o Counter[T]) {
+	for item, n := range o {
+		c[item] += n
+	}
+}
+
+// Subtract takes every count in o away from the matching count in c.
+// Items whose count reaches exactly 0 are removed; counts may go negative.
+func (c Counter[T]) Subtract(o Counter[T]) {
+	for item, n := range o {
+		c[item] -= n
+		if c[item] == 0 {
+			delete(c, item)
+		}
+	}
+}
+
+// CountRunes counts the occurrences of every rune in a string.
+// It returns a Counter of runes.
+func CountRunes(s string) Counter[rune] {
+	return CountSlice([]rune(s))
+}
+
+// A type representing an item and how many times it was counted.
+type ItemCount[T comparable] struct {
+	Item  T
+	Count int
+}
+
+// MostCommon lists the n most frequent items in a counter, by count descending.
+// Items with equal counts are ordered by tiebreak, which returns a negative number when a comes before b,
+// as cmp.Compare does for ordered types; if tiebreak is nil, their order is unspecified and may differ
+// between runs, including which tied items make the cut. MostCommonOrdered always breaks ties for ordered types.
+// If n is negative or larger than the number of items, every item is listed.
+// It returns a slice of ItemCounts.
+func (c Counter[T]) MostCommon(n int, tiebreak func(a, b T) int) []ItemCount[T] {
+	counts := make([]ItemCount[T], 0, len(c))
+	for item, count := range c {
+		counts = append(counts, ItemCount[T]{Item: item, Count: count})
+	}
+	slices.SortFunc(counts, func(a, b ItemCount[T]) int {
+		if a.Count != b.Count || tiebreak == nil {
+			return cmp.Compare(b.Count, a.Count)
+		}
+		return tiebreak(a.Item, b.Item)
+	})
+	if n >= 0 && n < len(counts) {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+// MostCommonOrdered lists the n most frequent items in a counter of ordered items, by count descending,
+// breaking ties by putting the smaller item first so the result is the same on every run.
+// It is c.MostCommon(n, cmp.Compare), for the common case of counting runes, strings or numbers.
+// It returns a slice of ItemCounts.
+func MostCommonOrdered[T cmp.Ordered](c Counter[T], n int) []ItemCount[T] {
+	return c.MostCommon(n, cmp.Compare[T])
+}
+
+// Sets
+
+// A type representing an unordered collection of distinct items of type T.
+type Set[T comparable] map[T]struct{}
+
+// NewSet creates a set holding the given items.
+// It returns a Set of type T.
+func NewSet[T comparable](items ...T) Set[T] {
+	return SetFromSlice(items)
+}
+
+// SetFromSlice creates a set holding every element of a slice of type T, dropping duplicates.
+// It returns a Set of type T.
+func SetFromSlice[T comparable](slice []T) Set[T] {
+	s := make(Set[T], len(slice))
+	s.Add(slice...)
+	return s
+}
+
+// Add inserts the given items into a set.
+func (s Set[T]) Add(items ...T) {
+	for _, item := range items {
+		s[item] = struct{}{}
+	}
+}
+
+// Remove deletes the given items from a set, ignoring any that aren't in it.
+func (s Set[T]) Remove(items ...T) {
+	for _, item := range items {
+		delete(s, item)
+	}
+}
+
+// Contains checks if an item is in a set.
+// It returns a bool.
+func (s Set[T]) Contains(item T) bool {
+	_, ok := s[item]
+	return ok
+}
+
+// Len returns the number of items in a set.
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+// Items collects the items of a set in no particular order. Use SortedItems for a deterministic order.
+// It returns a slice of type T.
+func (s Set[T]) Items() []T {
+	return Keys(s)
+}
+
+// SortedItems collects the items of a set in ascending order.
+// It returns a slice of type T.
+func SortedItems[T cmp.Ordered](s Set[T]) []T {
+	return SortedKeys(s)
+}
+
+// Union creates a set of the items in either s or o.
+// It returns a new Set of type T.
+func (s Set[T]) Union(o Set[T]) Set[T] {
+	union := make(Set[T], len(s)+len(o))
+	for item := range s {
+		union.Add(item)
+	}
+	for item := range o {
+		union.Add(item)
+	}
+	return union
+}
+
+// Intersect creates a set of the items in both s and o.
+// It returns a new Set of type T.
+func (s Set[T]) Intersect(o Set[T]) Set[T] {
+	if len(o) < len(s) {
+		s, o = o, s
+	}
+	intersection := make(Set[T])
+	for item := range s {
+		if o.Contains(item) {
+			intersection.Add(item)
+		}
+	}
+	return intersection
+}
+
+// Difference creates a set of the items in s that are not in o.
+// It returns a new Set of type T.
+func (s Set[T]) Difference(o Set[T]) Set[T] {
+	difference := make(Set[T])
+	for item := range s {
+		if !o.Contains(item) {
+			difference.Add(item)
+		}
+	}
+	return difference
+}
+
+// SymmetricDifference creates a set of the items in exactly one of s and o.
+// It returns a new Set of type T.
+func (s Set[T]) SymmetricDifference(o Set[T]) Set[T] {
+	difference := s.Difference(o)
+	for item := range o {
+		if !s.Contains(item) {
+			difference.Add(item)
+		}
+	}
+	return difference
+}
+
+// SubsetOf checks if every item in s is also in o.
+// It returns a bool.
+func (s Set[T]) SubsetOf(o Set[T]) bool {
+	if len(s) > len(o) {
+		return false
+	}
+	for item := range s {
+		if !o.Contains(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// Bits
+
+// checkBit panics if i is not a valid bit index for an int.
+func checkBit(i int) {
+	if i < 0 || i >= bits.UintSize {
+		panic(fmt.Sprintf("bit index %d out of range [0, %d)", i, bits.UintSize))
+	}
+}
+
+// PopCount returns the number of set bits in n. Negative numbers are counted in two's complement.
+func PopCount(n int) int {
+	return bits.OnesCount(uint(n))
+}
+
+// HasBit checks if bit i of n is set.
+// It will panic if i is not a valid bit index.
+// It returns a bool.
+func HasBit(n, i int) bool {
+	checkBit(i)
+	return n&(1<<i) != 0
+}
+
+// SetBit returns n with bit i set.
+// It will panic if i is not a valid bit index.
+func SetBit(n, i int) int {
+	checkBit(i)
+	return n | 1<<i
+}
+
+// ClearBit returns n with bit i cleared.
+// It will panic if i is not a valid bit index.
+func ClearBit(n, i int) int {
+	checkBit(i)
+	return n &^ (1 << i)
+}
+
+// ToggleBit returns n with bit i flipped.
+// It will panic if i is not a valid bit index.
+func ToggleBit(n, i int) int {
+	checkBit(i)
+	return n ^ 1<<i
+}
+
+// MaskRange returns an int with bits lo through hi inclusive set, so MaskRange(0, 3) returns 0b1111.
+// It will panic if lo or hi is not a valid bit index or lo is greater than hi.
+func MaskRange(lo, hi int) int {
+	checkBit(lo)
+	checkBit(hi)
+	if lo > hi {
+		panic(fmt.Sprintf("MaskRange: lo %d is greater than hi %d", lo, hi))
+	}
+	return int(^uint(0) >> (bits.UintSize - 1 - hi) &^ (1<<lo - 1))
+}
+
+// BitsSet lists the indices of the set bits of n, lowest first, for iterating over the members of a bitmask.
+// It returns a slice of ints.
+func BitsSet(n int) []int {
+	indices := make([]int, 0, PopCount(n))
+	for u := uint(n); u != 0; u &= u - 1 {
+		indices = append(indices, bits.TrailingZeros(u))
+	}
+	return indices
+}
+
+// A type representing a set of non-negative ints stored as bits, backed by a slice of uint64 words.
+// It grows as needed. The zero value is an empty set ready to use.
+type BitSet struct {
+	words []uint64
+}
+
+// NewBitSet creates a bit set with room for the indices 0 to size-1 before it needs to grow.
+// It returns a pointer to the BitSet.
+func NewBitSet(size int) *BitSet {
+	return &BitSet{words: make([]uint64, (size+63)/64)}
+}
+
+// checkIndex panics if i is negative.
+func (b *BitSet) checkIndex(i int) {
+	if i < 0 {
+		panic(fmt.Sprintf("BitSet: negative index %d", i))
+	}
+}
+
+// Set adds i to a bit set.
+func (b *BitSet) Set(i int) {
+	b.checkIndex(i)
+	for i/64 >= len(b.words) {
+		b.words = append(b.words, 0)
+	}
+	b.words[i/64] |= 1 << (i % 64)
+}
+
+// Clear removes i from a bit set.
+func (b *BitSet) Clear(i int) {
+	b.checkIndex(i)
+	if i/64 < len(b.words) {
+		b.words[i/64] &^= 1 << (i % 64)
+	}
+}
+
+// Get checks if i is in a bit set.
+// It returns a bool.
+func (b *BitSet) Get(i int) bool {
+	b.checkIndex(i)
+	return i/64 < len(b.words) && b.words[i/64]&(1<<(i%64)) != 0
+}
+
+// Count returns the number of elements in a bit set.
+func (b *BitSet) Count() (count int) {
+	for _, w := range b.words {
+		count += bits.OnesCount64(w)
+	}
+	return
+}
+
+// combine applies op word by word to two bit sets, treating missing words as zero.
+func (b *BitSet) combine(o *BitSet, op func(x, y uint64) uint64) *BitSet {
+	words := make([]uint64, max(len(b.words), len(o.words)))
+	for i := range words {
+		var x, y uint64
+		if i < len(b.words) {
+			x = b.words[i]
+		}
+		if i < len(o.words) {
+			y = o.words[i]
+		}
+		words[i] = op(x, y)
+	}
+	return &BitSet{words: words}
+}
+
+// And computes the intersection of two bit sets.
+// It returns a pointer to a new BitSet.
+func (b *BitSet) And(o *BitSet) *BitSet {
+	return b.combine(o, func(x, y uint64) uint64 { return x & y })
+}
+
+// Or computes the union of two bit sets.
+// It returns a pointer to a new BitSet.
+func (b *BitSet) Or(o *BitSet) *BitSet {
+	return b.combine(o, func(x, y uint64) uint64 { return x | y })
+}
+
+// Xor computes the symmetric difference of two bit sets.
+// It returns a pointer to a new BitSet.
+func (b *BitSet) Xor(o *BitSet) *BitSet {
+	return b.combine(o, func(x, y uint64) uint64 { return x ^ y })
+}
+
+// Intervals
+
+// A type representing the inclusive range of ints from Lo to Hi.
+type Interval struct{ Lo, Hi int }
+
+// ParseInterval parses an interval of two signed integers separated by sep, as described by ParseRange.
+// It will panic if either side doesn't contain exactly one integer, or if the lower bound is greater than the upper bound.
+// It returns an Interval.
+func ParseInterval(s string, sep string) Interval {
+	lo, hi := ParseRange(s, sep)
+	return Interval{Lo: lo, Hi: hi}
+}
+
+// Contains checks if n is inside an interval.
+// It returns a bool.
+func (i Interval) Contains(n int) bool {
+	return i.Lo <= n && n <= i.Hi
+}
+
+// Overlaps checks if two intervals share at least one value.
+// It returns a bool.
+func (i Interval) Overlaps(o Interval) bool {
+	return i.Lo <= o.Hi && o.Lo <= i.Hi
+}
+
+// Intersect finds the values shared by two intervals.
+// It returns the shared Interval and true, or the zero Interval and false if they don't overlap.
+func (i Interval) Intersect(o Interval) (Interval, bool) {
+	if !i.Overlaps(o) {
+		return Interval{}, false
+	}
+	return Interval{Lo: max(i.Lo, o.Lo), Hi: min(i.Hi, o.Hi)}, true
+}
+
+// MergeIntervals sorts a copy of the given intervals and coalesces any that overlap or are adjacent,
+// so [1-3] and [4-6] become [1-6]. The original slice is left untouched.
+// It returns a new slice of non-overlapping Intervals in ascending order.
+func MergeIntervals(intervals []Interval) []Interval {
+	sorted := SortedFunc(intervals, func(a, b Interval) bool { return a.Lo < b.Lo })
+	merged := make([]Interval, 0, len(sorted))
+	for _, next := range sorted {
+		if n := len(merged); n > 0 && (next.Lo <= merged[n-1].Hi || next.Lo-merged[n-1].Hi == 1) {
+			merged[n-1].Hi = max(merged[n-1].Hi, next.Hi)
+			continue
+		}
+		merged = append(merged, next)
+	}
+	return merged
+}
+
+// Caching
+
+// A type representing a cache holding at most a fixed number of entries, evicting the least recently used
+// entry to make room for a new one. Create one with NewBoundedCache.
+type BoundedCache[K comparable, V any] struct {
+	capacity int
+	order    *list.List
+	entries  map[K]*list.Element
+}
+
+// A type representing a key and value stored in a BoundedCache.
+type cacheEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// NewBoundedCache creates an empty cache that holds at most capacity entries.
+// It will panic if capacity is less than 1.
+// It returns a pointer to the BoundedCache.
+func NewBoundedCache[K comparable, V any](capacity int) *BoundedCache[K, V] {
+	if capacity < 1 {
+		panic(fmt.Sprintf("NewBoundedCache: capacity must be at least 1, got %d", capacity))
+	}
+	return &BoundedCache[K, V]{capacity: capacity, order: list.New(), entries: make(map[K]*list.Element)}
+}
+
+// Len returns the number of entries in a cache.
+func (c *BoundedCache[K, V]) Len() int {
+	return c.order.Len()
+}
+
+// Get looks up a key in a cache, marking it as the most recently used.
+// It returns the value and true, or the zero value and false if the key isn't cached.
+func (c *BoundedCache[K, V]) Get(key K) (V, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return *new(V), false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(cacheEntry[K, V]).value, true
+}
+
+// Put stores a value in a cache as the most recently used entry,
+// evicting the least recently used entry if the cache is full.
+func (c *BoundedCache[K, V]) Put(key K, value V) {
+	if e, ok := c.entries[key]; ok {
+		e.Value = cacheEntry[K, V]{key, value}
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.order.Len() == c.capacity {
+		oldest := c.order.Back()
+		delete(c.entries, oldest.Value.(cacheEntry[K, V]).key)
+		c.order.Remove(oldest)
+	}
+	c.entries[key] = c.order.PushFront(cacheEntry[K, V]{key, value})
+}
+
+// Memoize wraps f so each result is computed once and remembered for every later call with the same argument.
+// For a recursive function, declare the variable first so f can call the memoized version:
+//
+//	var fib func(int) int
+//	fib = Memoize(func(n int) int { ... fib(n-1) + fib(n-2) })
+//
+// It returns the memoized function.
+func Memoize[K comparable, V any](f func(K) V) func(K) V {
+	cache := make(map[K]V)
+	return func(key K) V {
+		if v, ok := cache[key]; ok {
+			return v
+		}
+		v := f(key)
+		cache[key] = v
+		return v
+	}
+}
+
+// MemoizeBounded wraps f like Memoize, but only remembers the capacity most recently used results,
+// for recursions whose key space is too large to keep every result in memory.
+// It will panic if capacity is less than 1.
+// It returns the memoized function.
+func MemoizeBounded[K comparable, V any](f func(K) V, capacity int) func(K) V {
+	cache := NewBoundedCache[K, V](capacity)
+	return func(key K) V {
+		if v, ok := cache.Get(key); ok {
+			return v
+		}
+		v := f(key)
+		cache.Put(key, v)
+		return v
+	}
+}
+
+// Iteration
+
+// FindCycle repeatedly applies step to a state, starting from initial, until a state repeats.
+// It never returns if the sequence of states does not repeat; use FindCycleN to bound the search.
+// It returns the index of the first state in the cycle and the length of the cycle.
+func FindCycle[T comparable](initial T, step func(T) T) (start, length int) {
+	start, length, _ = FindCycleN(initial, step, math.MaxInt)
+	return
+}
+
+// FindCycleN repeatedly applies step to a state, starting from initial, until a state repeats
+// or step has been applied maxIters times.
+// The state after n iterations, for any n at or beyond start, equals the state after start + (n-start)%length.
+// It returns the index of the first state in the cycle, the length of the cycle, and true,
+// or 0, 0 and false if no state repeated within maxIters steps.
+func FindCycleN[T comparable](initial T, step func(T) T, maxIters int) (start, length int, found bool) {
+	seen := make(map[T]int)
+	state := initial
+	for i := 0; ; i++ {
+		if j, ok := seen[state]; ok {
+			return j, i - j, true
+		}
+		if i == maxIters {
+			return 0, 0, false
+		}
+		seen[state] = i
+		state = step(state)
+	}
+}
+
+// IterateN applies step to initial n times, using the first repeated state to skip ahead
+// rather than running all n steps.
+// It returns the state after n steps.
+func IterateN[T comparable](initial T, step func(T) T, n int) T {
+	seen := make(map[T]int)
+	history := make([]T, 0)
+	state := initial
+	for i := 0; i < n; i++ {
+		if j, ok := seen[state]; ok {
+			return history[j+(n-j)%(i-j)]
+		}
+		seen[state] = i
+		history = append(history, state)
+		state = step(state)
+	}
+	return state
+}
+
+// FindCycleKeyed runs a simulation that isn't comparable, such as one holding a grid, until the iteration target
+// or until its state repeats, whichever comes first. step advances the caller's simulation by one iteration,
+// and key returns a string that uniquely identifies its current state, such as the grid serialized to a string.
+// If a cycle is found, step is called just enough extra times to leave the simulation in the state it would
+// have after target iterations, without running them all.
+// It returns the earliest iteration whose state matches the state after target iterations.
+func FindCycleKeyed(step func(), key func() string, target int) int {
+	seen := make(map[string]int)
+	for i := 0; i < target; i++ {
+		k := key()
+		if j, ok := seen[k]; ok {
+			length := i - j
+			for r := 0; r < (target-i)%length; r++ {
+				step()
+			}
+			return j + (target-j)%length
+		}
+		seen[k] = i
+		step()
+	}
+	return target
+}
+
+// Searching
+
+// BinarySearch finds the smallest value in [lo, hi] for which pred returns true,
+// assuming pred is monotone (false up to some point, then true from there on).
+// The range stays closed throughout, so any bounds work, including math.MinInt and math.MaxInt.
+// It returns the value, or hi+1 if pred is false everywhere in the range or the range is empty.
+// When hi is math.MaxInt, hi+1 wraps to math.MinInt.
+func BinarySearch(lo, hi int, pred func(int) bool) int {
+	if lo > hi {
+		return hi + 1
+	}
+	found := false
+	for lo < hi {
+		mid := Midpoint(lo, hi)
+		if pred(mid) {
+			hi, found = mid, true
+		} else {
+			lo = mid + 1
+		}
+	}
+	if found || pred(hi) {
+		return hi
+	}
+	return hi + 1
+}
+
+// BisectFloat narrows down the smallest value in [lo, hi] for which pred returns true, to within tol,
+// assuming pred is monotone (false up to some point, then true from there on).
+// It returns the value and true, or hi and false if pred is false even at hi.
+func BisectFloat(lo, hi, tol float64, pred func(float64) bool) (float64, bool) {
+	if !pred(hi) {
+		return hi, false
+	}
+	for hi-lo > tol {
+		mid := lo + (hi-lo)/2
+		if mid == lo || mid == hi {
+			// lo and hi are adjacent floats, so tol is finer than float64 can resolve.
+			break
+		}
+		if pred(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi, true
+}
+
+// BinarySearchSlice searches a slice sorted in ascending order for target.
+// It returns the index of target and true if it is found, or the index where target
+// would be inserted to keep the slice sorted and false if not.
+func BinarySearchSlice[T cmp.Ordered](slice []T, target T) (int, bool) {
+	return slices.BinarySearch(slice, target)
+}
+
+// Graphs
+
+// TopoSort orders nodes so that for every edge a -> b in edges, a comes before b.
+// edges maps each node to the nodes that must come after it. Nodes that only appear in edges are included.
+// Whenever several nodes are ready, the one listed earliest in nodes is picked; nodes that only appear
+// in edges come after all of nodes, in the order they are first found.
+// It returns a slice of type T, or an error listing a cycle if no ordering exists.
+func TopoSort[T comparable](nodes []T, edges map[T][]T) ([]T, error) {
+	return topoSort(nodes, edges, nil)
+}
+
+// TopoSortStable orders nodes like TopoSort, but whenever several nodes are ready
+// it picks the smallest according to less, such as alphabetical order for step names.
+// It returns a slice of type T, or an error listing a cycle if no ordering exists.
+func TopoSortStable[T comparable](nodes []T, edges map[T][]T, less func(a, b T) bool) ([]T, error) {
+	return topoSort(nodes, edges, less)
+}
+
+// topoSort runs Kahn's algorithm, emitting the smallest ready node according to less,
+// or the earliest ready node in discovery order if less is nil.
+func topoSort[T comparable](nodes []T, edges map[T][]T, less func(a, b T) bool) ([]T, error) {
+	all := make([]T, 0, len(nodes))
+	indegree := make(map[T]int)
+	position := make(map[T]int)
+	addNode := func(n T) {
+		if _, ok := indegree[n]; !ok {
+			indegree[n] = 0
+			position[n] = len(all)
+			all = append(all, n)
+		}
+	}
+	for _, n := range nodes {
+		addNode(n)
+	}
+	for _, n := range nodes {
+		for _, m := range edges[n] {
+			addNode(m)
+		}
+	}
+	for i := 0; i < len(all); i++ {
+		for _, m := range edges[all[i]] {
+			addNode(m)
+			indegree[m]++
+		}
+	}
+	if less == nil {
+		less = func(a, b T) bool { return position[a] < position[b] }
+	}
+
+	ready := make([]T, 0)
+	for _, n := range all {
+		if indegree[n] == 0 {
+			ready = append(ready, n)
+		}
+	}
+	order := make([]T, 0, len(all))
+	for len(ready) > 0 {
+		i := 0
+		for j := range ready {
+			if less(ready[j], ready[i]) {
+				i = j
+			}
+		}
+		n := ready[i]
+		ready = append(ready[:i], ready[i+1:]...)
+		order = append(order, n)
+		for _, m := range edges[n] {
+			indegree[m]--
+			if indegree[m] == 0 {
+				ready = append(ready, m)
+			}
+		}
+	}
+	if len(order) < len(all) {
+		return nil, fmt.Errorf("TopoSort: cycle detected: %v", findGraphCycle(all, edges, indegree))
+	}
+	return order, nil
+}
+
+// findGraphCycle finds a cycle among the nodes Kahn's algorithm could not emit.
+// Every such node has a predecessor that also wasn't emitted, so walking
+// predecessors must eventually revisit a node.
+func findGraphCycle[T comparable](all []T, edges map[T][]T, indegree map[T]int) []T {
+	pred := make(map[T]T)
+	for _, n := range all {
+		if indegree[n] == 0 {
+			continue
+		}
+		for _, m := range edges[n] {
+			if indegree[m] > 0 {
+				pred[m] = n
+			}
+		}
+	}
+	var n T
+	for _, n = range all {
+		if indegree[n] > 0 {
+			break
+		}
+	}
+	seen := make(map[T]bool)
+	for !seen[n] {
+		seen[n] = true
+		n = pred[n]
+	}
+	cycle := []T{n}
+	for m := pred[n]; m != n; m = pred[m] {
+		cycle = append(cycle, m)
+	}
+	for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+		cycle[i], cycle[j] = cycle[j], cycle[i]
+	}
+	return append(cycle, cycle[0])
+}
+
+// A type representing a disjoint-set forest over items of type T, using path compression and union by rank.
+// Items are added the first time they are passed to any method. The zero value is ready to use.
+type UnionFind[T comparable] struct {
+	parent map[T]T
+	rank   map[T]int
+	items  []T
+}
+
+// add registers an item as its own set if it hasn't been seen before.
+func (u *UnionFind[T]) add(a T) {
+	if u.parent == nil {
+		u.parent = make(map[T]T)
+		u.rank = make(map[T]int)
+	}
+	if _, ok := u.parent[a]; !ok {
+		u.parent[a] = a
+		u.items = append(u.items, a)
+	}
+}
+
+// Find returns the representative item of the set containing a.
+func (u *UnionFind[T]) Find(a T) T {
+	u.add(a)
+	root := a
+	for u.parent[root] != root {
+		root = u.parent[root]
+	}
+	for u.parent[a] != root {
+		a, u.parent[a] = u.parent[a], root
+	}
+	return root
+}
+
+// Union merges the sets containing a and b.
+func (u *UnionFind[T]) Union(a, b T) {
+	ra, rb := u.Find(a), u.Find(b)
+	if ra == rb {
+		return
+	}
+	if u.rank[ra] < u.rank[rb] {
+		ra, rb = rb, ra
+	}
+	u.parent[rb] = ra
+	if u.rank[ra] == u.rank[rb] {
+		u.rank[ra]++
+	}
+}
+
+// Connected checks if a and b are in the same set.
+// It returns a bool.
+func (u *UnionFind[T]) Connected(a, b T) bool {
+	return u.Find(a) == u.Find(b)
+}
+
+// Groups collects every set, ordered by the first time any of its items was seen,
+// with each set's items in the order they were first seen.
+// It returns a slice of slices of type T.
+func (u *UnionFind[T]) Groups() [][]T {
+	index := make(map[T]int)
+	groups := make([][]T, 0)
+	for _, item := range u.items {
+		root := u.Find(item)
+		i, ok := index[root]
+		if !ok {
+			i = len(groups)
+			index[root] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], item)
+	}
+	return groups
+}
+
+// Grid Utils
+
+// A type representing a slice of slices of type T
+type Grid[T any] [][]T
+
+// A type representing an X and Y coordinate pair
+type Coordinate struct{ X, Y int }
+
+var coordinatePattern = regexp.MustCompile(`(-?\d+)\s*,\s*(-?\d+)`)
+
+// ParseCoordinate attempts to parse a coordinate from a string of the form "x,y".
+// Spaces around either value are allowed.
+// It will panic if the string is not a valid coordinate.
+// It returns a Coordinate.
+func ParseCoordinate(s string) Coordinate {
+	x, y, found := strings.Cut(s, ",")
+	if !found {
+		panic(fmt.Sprintf("ParseCoordinate: missing ',' in %q", s))
+	}
+	return Coordinate{X: StrToInt(strings.TrimSpace(x)), Y: StrToInt(strings.TrimSpace(y))}
+}
+
+// ParseCoordinates finds every "x,y" pair in a longer string, such as "498,4 -> 498,6".
+// It returns a slice of Coordinates in the order they appear.
+func ParseCoordinates(s string) []Coordinate {
+	coords := make([]Coordinate, 0)
+	for _, match := range coordinatePattern.FindAllStringSubmatch(s, -1) {
+		coords = append(coords, Coordinate{X: StrToInt(match[1]), Y: StrToInt(match[2])})
+	}
+	return coords
+}
+
+// String formats a coordinate as "x,y", the inverse of ParseCoordinate.
+func (c Coordinate) String() string {
+	return fmt.Sprintf("%d,%d", c.X, c.Y)
+}
+
+// ManhattanDistance returns the taxicab distance |a.X-b.X| + |a.Y-b.Y| between two coordinates.
+func ManhattanDistance(a, b Coordinate) int {
+	return ManhattanDist(a.X, a.Y, b.X, b.Y)
+}
+
+// ManhattanTo returns the taxicab distance from c to o, as described by ManhattanDistance.
+func (c Coordinate) ManhattanTo(o Coordinate) int {
+	return ManhattanDistance(c, o)
+}
+
+// ChebyshevDistance returns the chessboard distance max(|a.X-b.X|, |a.Y-b.Y|) between two coordinates.
+func ChebyshevDistance(a, b Coordinate) int {
+	return Chebyshev(a.X, a.Y, b.X, b.Y)
+}
+
+// DistanceSquared returns the square of the straight-line distance between two coordinates,
+// for comparing distances without a square root or floating-point error.
+// With a 64-bit int the result is exact while |a.X-b.X| and |a.Y-b.Y| are below 2^31,
+// which covers any coordinates within ±1e9.
+func DistanceSquared(a, b Coordinate) int {
+	return EuclideanSq(a.X, a.Y, b.X, b.Y)
+}
+
+// EuclideanDistance returns the straight-line distance between two coordinates.
+func EuclideanDistance(a, b Coordinate) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+// Touching checks if two coordinates are the same or adjacent, including diagonally,
+// meaning their Chebyshev distance is at most 1.
+// It returns a bool.
+func Touching(a, b Coordinate) bool {
+	return ChebyshevDistance(a, b) <= 1
+}
+
+type Direction int
+
+const (
+	N Direction = iota
+	NE
+	E
+	SE
+	S
+	SW
+	W
+	NW
+)
+
+var Offsets = map[Direction]Coordinate{
+	N:  {X: 0, Y: -1},
+	NE: {X: 1, Y: -1},
+	E:  {X: 1, Y: 0},
+	SE: {X: 1, Y: 1},
+	S:  {X: 0, Y: 1},
+	SW: {X: -1, Y: 1},
+	W:  {X: -1, Y: 0},
+	NW: {X: -1, Y: -1},
+}
+
+// The four orthogonal directions, clockwise from north.
+var orthogonal = []Direction{N, E, S, W}
+
+// All eight directions, clockwise from north.
+var allDirections = []Direction{N, NE, E, SE, S, SW, W, NW}
+
+// A type representing a coordinate in a grid together with the value stored there.
+type GridCell[T any] struct {
+	Coord Coordinate
+	Value T
+}
+
+// neighbors collects the in-bounds coordinates one step from c in each of the given directions.
+func (g Grid[T]) neighbors(c Coordinate, dirs []Direction) []Coordinate {
+	coords := make([]Coordinate, 0, len(dirs))
+	for _, d := range dirs {
+		n := Coordinate{X: c.X + Offsets[d].X, Y: c.Y + Offsets[d].Y}
+		if InBounds(g, n) {
+			coords = append(coords, n)
+		}
+	}
+	return coords
+}
+
+// neighborValues pairs each in-bounds neighbor of c in the given directions with its value.
+func (g Grid[T]) neighborValues(c Coordinate, dirs []Direction) []GridCell[T] {
+	coords := g.neighbors(c, dirs)
+	cells := make([]GridCell[T], len(coords))
+	for i, n := range coords {
+		cells[i] = GridCell[T]{Coord: n, Value: g[n.Y][n.X]}
+	}
+	return cells
+}
+
+// Neighbors4 finds the orthogonal neighbors of a coordinate that are inside a grid,
+// in the order N, E, S, W.
+// It returns a slice of Coordinates.
+func (g Grid[T]) Neighbors4(c Coordinate) []Coordinate {
+	return g.neighbors(c, orthogonal)
+}
+
+// Neighbors8 finds the orthogonal and diagonal neighbors of a coordinate that are inside a grid,
+// clockwise from N.
+// It returns a slice of Coordinates.
+func (g Grid[T]) Neighbors8(c Coordinate) []Coordinate {
+	return g.neighbors(c, allDirections)
+}
+
+// NeighborValues4 finds the orthogonal neighbors of a coordinate that are inside a grid,
+// in the order N, E, S, W, along with their values.
+// It returns a slice of GridCells of type T.
+func (g Grid[T]) NeighborValues4(c Coordinate) []GridCell[T] {
+	return g.neighborValues(c, orthogonal)
+}
+
+// NeighborValues8 finds the orthogonal and diagonal neighbors of a coordinate that are inside a grid,
+// clockwise from N, along with their values.
+// It returns a slice of GridCells of type T.
+func (g Grid[T]) NeighborValues8(c Coordinate) []GridCell[T] {
+	return g.neighborValues(c, allDirections)
+}
+
+// AtWrapped returns the value at a coordinate, wrapping X and Y around the edges of a grid
+// as if it were tiled infinitely, so negative coordinates count back from the opposite edge.
+// The grid is assumed to be rectangular.
+// It returns a value of type T.
+func (g Grid[T]) AtWrapped(c Coordinate) T {
+	y := Mod(c.Y, len(g))
+	return g[y][Mod(c.X, len(g[y]))]
+}
+
+// AtWrappedX returns the value at a coordinate, wrapping only X around the edges of a grid,
+// for puzzles where the grid repeats horizontally.
+// It will panic if Y is outside the grid.
+// It returns a value of type T.
+func (g Grid[T]) AtWrappedX(c Coordinate) T {
+	return g[c.Y][Mod(c.X, len(g[c.Y]))]
+}
+
+// NeighborsWrapped4 finds the orthogonal neighbors of a coordinate on a grid whose edges wrap around,
+// in the order N, E, S, W. Each neighbor is normalized into the bounds of the grid.
+// The grid is assumed to be rectangular.
+// It returns a slice of Coordinates.
+func (g Grid[T]) NeighborsWrapped4(c Coordinate) []Coordinate {
+	coords := make([]Coordinate, len(orthogonal))
+	for i, d := range orthogonal {
+		coords[i] = Coordinate{X: Mod(c.X+Offsets[d].X, len(g[0])), Y: Mod(c.Y+Offsets[d].Y, len(g))}
+	}
+	return coords
+}
+
+// InBounds checks if the given coordinates are in the bounds of a given grid.
+// The grid is assumed to be square
+// It returns a bool.
+func InBounds[T any](grid Grid[T], coord Coordinate) bool {
+	return coord.Y >= 0 && coord.X >= 0 && coord.Y <= len(grid)-1 && coord.X <= len(grid[0])-1
+}
+
+// GridFromStrings converts a slice of lines into a grid of runes, one row per line.
+// It returns a grid of runes.
+func GridFromStrings(lines []string) Grid[rune] {
+	grid := make(Grid[rune], len(lines))
+	for y, line := range lines {
+		grid[y] = []rune(line)
+	}
+	return grid
+}
+
+// ByteGridFromStrings converts a slice of lines into a grid of bytes, one row per line.
+// It returns a grid of bytes.
+func ByteGridFromStrings(lines []string) Grid[byte] {
+	grid := make(Grid[byte], len(lines))
+	for y, line := range lines {
+		grid[y] = []byte(line)
+	}
+	return grid
+}
+
+// GridFromCoordinateMap renders a sparse map of coordinates into a dense grid just large enough to hold
+// every key, shifted so the smallest X and Y land at 0. Cells with no entry in the map are set to fill.
+// It returns a grid of type T, which is empty if the map is.
+func GridFromCoordinateMap[T any](m map[Coordinate]T, fill T) Grid[T] {
+	if len(m) == 0 {
+		return Grid[T]{}
+	}
+	lo := Coordinate{X: math.MaxInt, Y: math.MaxInt}
+	hi := Coordinate{X: math.MinInt, Y: math.MinInt}
+	for c := range m {
+		lo = Coordinate{X: min(lo.X, c.X), Y: min(lo.Y, c.Y)}
+		hi = Coordinate{X: max(hi.X, c.X), Y: max(hi.Y, c.Y)}
+	}
+	grid := make(Grid[T], hi.Y-lo.Y+1)
+	for y := range grid {
+		grid[y] = make([]T, hi.X-lo.X+1)
+		for x := range grid[y] {
+			grid[y][x] = fill
+		}
+	}
+	for c, v := range m {
+		grid[c.Y-lo.Y][c.X-lo.X] = v
+	}
+	return grid
+}
+
+// ParseGrid converts a slice of lines into a grid of type T, translating each rune through mapping.
+// It returns a grid of type T, or an error naming the first rune that isn't in mapping and where it was found.
+func ParseGrid[T any](lines []string, mapping map[rune]T) (Grid[T], error) {
+	grid := make(Grid[T], len(lines))
+	for y, line := range lines {
+		grid[y] = make([]T, 0, len(line))
+		for x, r := range []rune(line) {
+			v, ok := mapping[r]
+			if !ok {
+				return nil, fmt.Errorf("ParseGrid: unknown rune %q at %d,%d", r, x, y)
+			}
+			grid[y] = append(grid[y], v)
+		}
+	}
+	return grid, nil
+}
+
+// ParseGridDefault converts a slice of lines into a grid of type T, translating each rune through mapping
+// and using def for any rune that isn't in mapping.
+// It returns a grid of type T.
+func ParseGridDefault[T any](lines []string, mapping map[rune]T, def T) Grid[T] {
+	grid := make(Grid[T], len(lines))
+	for y, line := range lines {
+		grid[y] = make([]T, 0, len(line))
+		for _, r := range line {
+			v, ok := mapping[r]
+			if !ok {
+				v = def
+			}
+			grid[y] = append(grid[y], v)
+		}
+	}
+	return grid
+}
+
+// GridEqual checks if two grids of type T have the same shape and elements.
+// It returns a bool.
+func GridEqual[T comparable](a, b Grid[T]) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for y := range a {
+		if !SliceEqual(a[y], b[y]) {
+			return false
+		}
+	}
+	return true
+}
+
+// GridClone makes a deep copy of a grid of type T, so the copy can be modified without affecting the original.
+// It returns a new grid of type T.
+func GridClone[T any](grid Grid[T]) Grid[T] {
+	clone := make(Grid[T], len(grid))
+	for y, row := range grid {
+		clone[y] = append(make([]T, 0, len(row)), row...)
+	}
+	return clone
+}
+
+// Subgrid copies the rectangle of a grid that is height rows tall and width columns wide,
+// with its top-left corner at row top and column left.
+// It will panic if the rectangle extends outside the grid.
+// It returns a new grid of type T.
+func (g Grid[T]) Subgrid(top, left, height, width int) Grid[T] {
+	if top < 0 || left < 0 || height < 0 || width < 0 || top+height > len(g) {
+		panic(fmt.Sprintf("Subgrid: rectangle at %d,%d of size %dx%d is outside the grid", left, top, width, height))
+	}
+	sub := make(Grid[T], height)
+	for y := range sub {
+		row := g[top+y]
+		if left+width > len(row) {
+			panic(fmt.Sprintf("Subgrid: rectangle at %d,%d of size %dx%d is outside the grid", left, top, width, height))
+		}
+		sub[y] = append([]T(nil), row[left:left+width]...)
+	}
+	return sub
+}
+
+// Pad surrounds a grid of type T with a border of the given thickness filled with fill.
+// The grid is assumed to be rectangular.
+// It returns a new grid of type T.
+func Pad[T any](grid Grid[T], thickness int, fill T) Grid[T] {
+	width := thickness * 2
+	if len(grid) > 0 {
+		width += len(grid[0])
+	}
+	padded := make(Grid[T], len(grid)+thickness*2)
+	for y := range padded {
+		padded[y] = make([]T, width)
+		for x := range padded[y] {
+			padded[y][x] = fill
+		}
+		if y >= thickness && y < thickness+len(grid) {
+			copy(padded[y][thickness:], grid[y-thickness])
+		}
+	}
+	return padded
+}
+
+// Crop removes the given number of rows from the top and bottom and columns from the left and right of a grid of type T.
+// Crop(Pad(grid, n, fill), n, n, n, n) returns a copy of grid.
+// It will panic if more rows or columns are removed than the grid has.
+// It returns a new grid of type T.
+func Crop[T any](grid Grid[T], top, bottom, left, right int) Grid[T] {
+	if top+bottom > len(grid) {
+		panic(fmt.Sprintf("Crop: cannot remove %d rows from a grid of height %d", top+bottom, len(grid)))
+	}
+	cropped := make(Grid[T], 0, len(grid)-top-bottom)
+	for _, row := range grid[top : len(grid)-bottom] {
+		if left+right > len(row) {
+			panic(fmt.Sprintf("Crop: cannot remove %d columns from a row of width %d", left+right, len(row)))
+		}
+		cropped = append(cropped, append([]T(nil), row[left:len(row)-right]...))
+	}
+	return cropped
+}
+
+// Column collects the element at index x of every row of a grid, top to bottom.
+// Rows too short to have an element at x are skipped.
+// It returns a slice of type T.
+func (g Grid[T]) Column(x int) []T {
+	column := make([]T, 0, len(g))
+	for _, row := range g {
+		if x >= 0 && x < len(row) {
+			column = append(column, row[x])
+		}
+	}
+	return column
+}
+
+// Columns collects every column of a grid, left to right, as described by Column.
+// It returns a slice of slices of type T.
+func (g Grid[T]) Columns() [][]T {
+	width := 0
+	for _, row := range g {
+		width = max(width, len(row))
+	}
+	columns := make([][]T, width)
+	for x := range columns {
+		columns[x] = g.Column(x)
+	}
+	return columns
+}
+
+// Diagonals collects every down-right diagonal of a grid, each read from top-left to bottom-right.
+// They are ordered from the one starting in the bottom-left corner to the one starting in the top-right corner.
+// The grid is assumed to be rectangular, not necessarily square.
+// It returns a slice of slices of type T.
+func (g Grid[T]) Diagonals() [][]T {
+	if len(g) == 0 {
+		return nil
+	}
+	height, width := len(g), len(g[0])
+	diagonals := make([][]T, 0, width+height-1)
+	for d := -(height - 1); d < width; d++ {
+		diagonal := make([]T, 0)
+		for y := max(0, -d); y < height && y+d < width; y++ {
+			diagonal = append(diagonal, g[y][y+d])
+		}
+		diagonals = append(diagonals, diagonal)
+	}
+	return diagonals
+}
+
+// AntiDiagonals collects every down-left diagonal of a grid, each read from top-right to bottom-left.
+// They are ordered from the one starting in the top-left corner to the one starting in the bottom-right corner.
+// The grid is assumed to be rectangular, not necessarily square.
+// It returns a slice of slices of type T.
+func (g Grid[T]) AntiDiagonals() [][]T {
+	if len(g) == 0 {
+		return nil
+	}
+	height, width := len(g), len(g[0])
+	diagonals := make([][]T, 0, width+height-1)
+	for sum := 0; sum < width+height-1; sum++ {
+		diagonal := make([]T, 0)
+		for x := min(sum, width-1); x >= 0 && sum-x < height; x-- {
+			diagonal = append(diagonal, g[sum-x][x])
+		}
+		diagonals = append(diagonals, diagonal)
+	}
+	return diagonals
+}
+
+// StepGrid builds the next generation of a grid by applying rule to every cell.
+// rule always reads from the old grid, so cells updated earlier in the step don't affect later ones.
+// It returns a new grid of type T.
+func StepGrid[T any](grid Grid[T], rule func(g Grid[T], c Coordinate) T) Grid[T] {
+	next := GridClone(grid)
+	stepInto(grid, next, rule)
+	return next
+}
+
+// StepGridN applies StepGrid n times, alternating between two buffers instead of allocating a grid per step.
+// The original grid is left untouched.
+// It returns a new grid of type T.
+func StepGridN[T any](grid Grid[T], rule func(g Grid[T], c Coordinate) T, n int) Grid[T] {
+	cur, next := GridClone(grid), GridClone(grid)
+	for i := 0; i < n; i++ {
+		stepInto(cur, next, rule)
+		cur, next = next, cur
+	}
+	return cur
+}
+
+// stepInto writes rule applied to every cell of cur into the matching cell of next.
+func stepInto[T any](cur, next Grid[T], rule func(g Grid[T], c Coordinate) T) {
+	for y, row := range cur {
+		for x := range row {
+			next[y][x] = rule(cur, Coordinate{X: x, Y: y})
+		}
+	}
+}
+
+// emptyLines marks which rows and columns of a grid consist entirely of empty.
+// The grid is assumed to be rectangular.
+func emptyLines[T comparable](grid Grid[T], empty T) (rows, cols []bool) {
+	rows = make([]bool, len(grid))
+	if len(grid) > 0 {
+		cols = make([]bool, len(grid[0]))
+	}
+	for x := range cols {
+		cols[x] = true
+	}
+	for y, row := range grid {
+		rows[y] = true
+		for x, v := range row {
+			if v != empty {
+				rows[y], cols[x] = false, false
+			}
+		}
+	}
+	return
+}
+
+// ExpandEmpty replaces every row and column of a grid that consists entirely of empty with factor copies of it,
+// so a factor of 2 doubles each empty line. The grid is assumed to be rectangular.
+// For large factors, ExpandEmptyCoords avoids building the expanded grid.
+// It will panic if factor is less than 1.
+// It returns a new grid of type T.
+func ExpandEmpty[T comparable](grid Grid[T], empty T, factor int) Grid[T] {
+	if factor < 1 {
+		panic(fmt.Sprintf("ExpandEmpty: factor must be at least 1, got %d", factor))
+	}
+	rows, cols := emptyLines(grid, empty)
+	copies := func(isEmpty bool) int {
+		if isEmpty {
+			return factor
+		}
+		return 1
+	}
+	expanded := make(Grid[T], 0, len(grid))
+	for y, row := range grid {
+		newRow := make([]T, 0, len(row))
+		for x, v := range row {
+			for i := 0; i < copies(cols[x]); i++ {
+				newRow = append(newRow, v)
+			}
+		}
+		for i := 0; i < copies(rows[y]); i++ {
+			expanded = append(expanded, append([]T(nil), newRow...))
+		}
+	}
+	return expanded
+}
+
+// ExpandEmptyCoords works out where each cell of a grid would end up after ExpandEmpty,
+// without building the expanded grid, so factors like 1000000 are cheap.
+// It will panic if factor is less than 1.
+// It returns a function mapping an original Coordinate to its expanded Coordinate.
+func ExpandEmptyCoords[T comparable](grid Grid[T], empty T, factor int) func(Coordinate) Coordinate {
+	if factor < 1 {
+		panic(fmt.Sprintf("ExpandEmptyCoords: factor must be at least 1, got %d", factor))
+	}
+	rows, cols := emptyLines(grid, empty)
+	// shift[i] is the extra offset added to index i by the empty lines before it.
+	shifts := func(empty []bool) []int {
+		shift := make([]int, len(empty))
+		for i := 1; i < len(empty); i++ {
+			shift[i] = shift[i-1]
+			if empty[i-1] {
+				shift[i] += factor - 1
+			}
+		}
+		return shift
+	}
+	rowShift, colShift := shifts(rows), shifts(cols)
+	return func(c Coordinate) Coordinate {
+		return Coordinate{X: c.X + colShift[c.X], Y: c.Y + rowShift[c.Y]}
+	}
+}
+
+// PrefixSum2D builds a summed-area table for a grid of ints, where table[y][x] is the sum of every
+// grid[j][i] with j <= y and i <= x (both inclusive). The table has the same shape as the grid.
+// The grid is assumed to be rectangular.
+// It returns a new grid of ints.
+func PrefixSum2D(grid Grid[int]) Grid[int] {
+	table := make(Grid[int], len(grid))
+	for y, row := range grid {
+		table[y] = make([]int, len(row))
+		rowSum := 0
+		for x, v := range row {
+			rowSum += v
+			table[y][x] = rowSum
+			if y > 0 {
+				table[y][x] += table[y-1][x]
+			}
+		}
+	}
+	return table
+}
+
+// PrefixCount2D builds a summed-area table counting the true cells of a grid of bools,
+// as described by PrefixSum2D, for use with RangeSum.
+// It returns a new grid of ints.
+func PrefixCount2D(grid Grid[bool]) Grid[int] {
+	counts := make(Grid[int], len(grid))
+	for y, row := range grid {
+		counts[y] = make([]int, len(row))
+		for x, v := range row {
+			if v {
+				counts[y][x] = 1
+			}
+		}
+	}
+	return PrefixSum2D(counts)
+}
+
+// RangeSum uses a table from PrefixSum2D to add up the rectangle of the original grid between
+// topLeft and bottomRight, both inclusive, in constant time. A single cell c is RangeSum(table, c, c).
+// It will panic if either corner is outside the table.
+// It returns an int.
+func RangeSum(table Grid[int], topLeft, bottomRight Coordinate) int {
+	sum := table[bottomRight.Y][bottomRight.X]
+	if topLeft.Y > 0 {
+		sum -= table[topLeft.Y-1][bottomRight.X]
+	}
+	if topLeft.X > 0 {
+		sum -= table[bottomRight.Y][topLeft.X-1]
+	}
+	if topLeft.Y > 0 && topLeft.X > 0 {
+		sum += table[topLeft.Y-1][topLeft.X-1]
+	}
+	return sum
+}
+
+// RowString converts the row at index y of a grid of runes to a string.
+// It returns a string.
+func RowString(grid Grid[rune], y int) string {
+	return string(grid[y])
+}
+
+// ClampCoordinate moves a coordinate to the nearest cell inside the bounds of a grid.
+// The grid is assumed to be rectangular.
+// It will panic if the grid is empty.
+// It returns a Coordinate.
+func ClampCoordinate[T any](c Coordinate, grid Grid[T]) Coordinate {
+	if len(grid) == 0 || len(grid[0]) == 0 {
+		panic("ClampCoordinate: grid is empty")
+	}
+	return Coordinate{X: Clamp(c.X, 0, len(grid[0])-1), Y: Clamp(c.Y, 0, len(grid)-1)}
+}
+
+// RayCast walks from start in steps of dir, collecting each cell visited until it leaves the grid
+// or stop returns true for a cell's value. The start cell is not included; the cell that stopped
+// the ray is, so len(RayCast(...)) is the viewing distance in line-of-sight puzzles.
+// It will panic if dir is the zero Coordinate.
+// It returns a slice of Coordinates in the order they were visited.
+func RayCast[T any](grid Grid[T], start, dir Coordinate, stop func(T) bool) []Coordinate {
+	if dir == (Coordinate{}) {
+		panic("RayCast: dir must not be zero")
+	}
+	path := make([]Coordinate, 0)
+	for c := (Coordinate{start.X + dir.X, start.Y + dir.Y}); InBounds(grid, c); c = (Coordinate{c.X + dir.X, c.Y + dir.Y}) {
+		path = append(path, c)
+		if stop(grid[c.Y][c.X]) {
+			break
+		}
+	}
+	return path
+}
+
+// TracePath follows a path through a grid from start, calling next with the previous and current cells
+// to choose each following cell. On the first call prev is the same as start, so next can tell it must pick
+// a direction. The trace ends when next returns false, steps outside the grid, or reaches a cell already on
+// the path; reaching start again means the path is a closed loop, as in pipe-maze puzzles.
+// The connection rule is left entirely to next.
+// It returns a slice of Coordinates in the order they were visited, beginning with start.
+func TracePath[T any](g Grid[T], start Coordinate, next func(g Grid[T], prev, cur Coordinate) (Coordinate, bool)) []Coordinate {
+	path := []Coordinate{start}
+	visited := map[Coordinate]bool{start: true}
+	prev, cur := start, start
+	for {
+		c, ok := next(g, prev, cur)
+		if !ok || !InBounds(g, c) || visited[c] {
+			return path
+		}
+		path = append(path, c)
+		visited[c] = true
+		prev, cur = cur, c
+	}
+}
+
+// The 6-row letter font drawn by several puzzles, with its letters side by side in the order of ocrLetters.
+var ocrRows = []string{
+	".##..###...##..####.####..##..#..#.###...##.#..#.#.....##..###..###...###.#..#.#...#.####",
+	"#..#.#..#.#..#.#....#....#..#.#..#..#.....#.#.#..#....#..#.#..#.#..#.#....#..#.#...#....#",
+	"#..#.###..#....###..###..#....####..#.....#.##...#....#..#.#..#.#..#.#....#..#..#.#....#.",
+	"####.#..#.#....#....#....#.##.#..#..#.....#.#.#..#....#..#.###..###...##..#..#...#....#..",
+	"#..#.#..#.#..#.#....#....#..#.#..#..#..#..#.#.#..#....#..#.#....#.#.....#.#..#...#...#...",
+	"#..#.###...##..####.#.....###.#..#.###..##..#..#.####..##..#....#..#.###...##....#...####",
+}
+
+const ocrLetters = "ABCEFGHIJKLOPRSUYZ"
+
+// The width of each letter cell on a puzzle's screen: four columns of glyph and one of gap, or a full five for Y.
+const ocrCellWidth = 5
+
+// Maps each glyph in ocrRows, as described by glyphAt, to its letter.
+var ocrFont = func() map[string]rune {
+	font := make(map[string]rune)
+	glyphs := gapGlyphs(GridFromStrings(ocrRows), '#')
+	for i, letter := range ocrLetters {
+		font[glyphs[i]] = letter
+	}
+	return font
+}()
+
+// litColumns reports, for each column of a grid, whether any of its cells is on.
+func litColumns(g Grid[rune], on rune) []bool {
+	width := 0
+	for _, row := range g {
+		width = max(width, len(row))
+	}
+	lit := make([]bool, width)
+	for _, row := range g {
+		for x, r := range row {
+			lit[x] = lit[x] || r == on
+		}
+	}
+	return lit
+}
+
+// glyphAt describes the columns from up to (not including) to of a grid, less any unlit columns on either side,
+// as its rows of '#' (on) and '.' (off) joined by newlines.
+// It returns the description, or "" if none of the columns are lit.
+func glyphAt(g Grid[rune], on rune, lit []bool, from, to int) string {
+	for from < to && !lit[from] {
+		from++
+	}
+	for to > from && !lit[to-1] {
+		to--
+	}
+	if from == to {
+		return ""
+	}
+	rows := make([]string, len(g))
+	for y, row := range g {
+		var sb strings.Builder
+		for x := from; x < to; x++ {
+			if x < len(row) && row[x] == on {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		rows[y] = sb.String()
+	}
+	return strings.Join(rows, "\n")
+}
+
+// cellGlyphs splits a grid into the glyphs in each ocrCellWidth-column cell, starting from the first lit column.
+func cellGlyphs(g Grid[rune], on rune) []string {
+	lit := litColumns(g, on)
+	glyphs := make([]string, 0)
+	for x := slices.Index(lit, true); x >= 0 && x < len(lit); x += ocrCellWidth {
+		if glyph := glyphAt(g, on, lit, x, min(x+ocrCellWidth, len(lit))); glyph != "" {
+			glyphs = append(glyphs, glyph)
+		}
+	}
+	return glyphs
+}
+
+// gapGlyphs splits a grid into the glyphs between its unlit columns.
+func gapGlyphs(g Grid[rune], on rune) []string {
+	lit := litColumns(g, on)
+	glyphs := make([]string, 0)
+	for x := 0; x < len(lit); x++ {
+		if !lit[x] {
+			continue
+		}
+		start := x
+		for x < len(lit) && lit[x] {
+			x++
+		}
+		glyphs = append(glyphs, glyphAt(g, on, lit, start, x))
+	}
+	return glyphs
+}
+
+// readGlyphs looks up each glyph in ocrFont, writing '?' for any it doesn't know.
+// It returns the letters and whether every glyph was known.
+func readGlyphs(glyphs []string) (string, bool) {
+	var sb strings.Builder
+	known := true
+	for _, glyph := range glyphs {
+		letter, ok := ocrFont[glyph]
+		if !ok {
+			letter, known = '?', false
+		}
+		sb.WriteRune(letter)
+	}
+	return sb.String(), known
+}
+
+// OCR reads the capital letters drawn in a grid using the 6-row pixel font from several puzzles,
+// where the cells equal to on are lit. Letters are read from the standard 5-column cells, starting
+// at the first lit column; if that leaves a glyph unrecognised, the grid is split on columns with
+// nothing lit instead, for text that isn't spaced on the standard grid. Rows with nothing lit above
+// or below the text are ignored, and any glyph still unrecognised becomes '?'.
+// Only the letters A B C E F G H I J K L O P R S U Y Z are known, and the larger 10-row font is not supported.
+// It will panic if the lit rows don't span exactly 6 rows.
+// It returns a string.
+func OCR(g Grid[rune], on rune) string {
+	lit := func(row []rune) bool {
+		return slices.Contains(row, on)
+	}
+	top := slices.IndexFunc(g, lit)
+	if top < 0 {
+		panic("OCR: grid has no lit cells")
+	}
+	bottom := len(g) - 1
+	for !lit(g[bottom]) {
+		bottom--
+	}
+	if bottom-top+1 != len(ocrRows) {
+		panic(fmt.Sprintf("OCR: text is %d rows tall, expected %d", bottom-top+1, len(ocrRows)))
+	}
+	text := g[top : bottom+1]
+	letters, ok := readGlyphs(cellGlyphs(text, on))
+	if !ok {
+		if byGaps, ok := readGlyphs(gapGlyphs(text, on)); ok {
+			return byGaps
+		}
+	}
+	return letters
+}
+
+// PrintGrid prints every element in a given grid separated by a given delimeter.
+func PrintGrid[T any](grid Grid[T], delim string) {
+	for _, row := range grid {
+		fmt.Print(delim)
+		for _, col := range row {
+			fmt.Print(col, delim)
+		}
+		println()
+	}
+}
+
+// A type representing an agent at a position in a grid, moving along a facing vector.
+// Y increases downwards, so turning right is clockwise on screen.
+// Facing is usually a unit offset such as Offsets[E], but any vector works, which lets it act as a waypoint.
+type Turtle struct {
+	Pos    Coordinate
+	Facing Coordinate
+}
+
+// NewTurtle creates a turtle at the given position, facing east.
+// It returns a pointer to the Turtle.
+func NewTurtle(pos Coordinate) *Turtle {
+	return &Turtle{Pos: pos, Facing: Offsets[E]}
+}
+
+// Forward moves a turtle n steps along its facing vector. A negative n moves it backwards.
+func (t *Turtle) Forward(n int) {
+	t.Pos.X += t.Facing.X * n
+	t.Pos.Y += t.Facing.Y * n
+}
+
+// TurnRight rotates a turtle's facing 90 degrees clockwise.
+func (t *Turtle) TurnRight() {
+	t.Facing = Coordinate{X: -t.Facing.Y, Y: t.Facing.X}
+}
+
+// TurnLeft rotates a turtle's facing 90 degrees counterclockwise.
+func (t *Turtle) TurnLeft() {
+	t.Facing = Coordinate{X: t.Facing.Y, Y: -t.Facing.X}
+}
+
+// TurnAround reverses a turtle's facing.
+func (t *Turtle) TurnAround() {
+	t.Facing = Coordinate{X: -t.Facing.X, Y: -t.Facing.Y}
+}
+
+// RotateDegrees rotates a turtle's facing by d degrees, clockwise for positive d and counterclockwise for negative d.
+// It will panic if d is not a multiple of 90.
+func (t *Turtle) RotateDegrees(d int) {
+	if d%90 != 0 {
+		panic(fmt.Sprintf("Turtle.RotateDegrees: %d is not a multiple of 90", d))
+	}
+	for range Mod(d, 360) / 90 {
+		t.TurnRight()
+	}
+}
+
+// Geometry
+
+// ShoelaceArea computes the area enclosed by a polygon with the given vertices using the shoelace formula.
+// Vertices may be in clockwise or counterclockwise order, and the last vertex joins back to the first.
+// The doubled area is always an integer; if it is odd, the halved result is rounded down.
+// It returns an int.
+func ShoelaceArea(poly []Coordinate) int {
+	doubled := 0
+	for i, a := range poly {
+		b := poly[(i+1)%len(poly)]
+		doubled += a.X*b.Y - b.X*a.Y
+	}
+	return Abs(doubled) / 2
+}
+
+// PerimeterLength counts the lattice points on the boundary of a polygon whose edges are all horizontal or vertical,
+// which is the sum of its edge lengths. The last vertex joins back to the first.
+// Together with ShoelaceArea it gives PicksInteriorPoints everything it needs.
+// It returns an int.
+func PerimeterLength(vertices []Coordinate) (length int) {
+	for i, a := range vertices {
+		length += ManhattanDistance(a, vertices[(i+1)%len(vertices)])
+	}
+	return
+}
+
+// PicksInteriorPoints uses Pick's theorem, A = I + B/2 - 1, to count the lattice points strictly inside
+// a polygon from its area and the number of lattice points on its boundary.
+// It returns an int.
+func PicksInteriorPoints(area, boundary int) int {
+	return area - boundary/2 + 1
+}
+
+// A type representing a cell in a hexagonal grid as cube coordinates, where X+Y+Z is always 0.
+// The grid is pointy-topped, so the six neighbors are in the directions "e", "se", "sw", "w", "nw" and "ne".
+// A flat-topped grid, whose directions are "n", "ne", "se", "s", "sw" and "nw", is this grid turned a quarter
+// turn, so relabel its directions clockwise ("n" becomes "e", "ne" becomes "se", "se" becomes "sw", "s" becomes "w",
+// "sw" becomes "nw" and "nw" becomes "ne"); distances are unaffected.
+type HexCoordinate struct{ X, Y, Z int }
+
+// HexOffsets maps each pointy-topped hex direction to the HexCoordinate one step away from the origin.
+var HexOffsets = map[string]HexCoordinate{
+	"e":  {X: 1, Y: 0, Z: -1},
+	"se": {X: 0, Y: 1, Z: -1},
+	"sw": {X: -1, Y: 1, Z: 0},
+	"w":  {X: -1, Y: 0, Z: 1},
+	"nw": {X: 0, Y: -1, Z: 1},
+	"ne": {X: 1, Y: -1, Z: 0},
+}
+
+// The six hex directions, clockwise from east.
+var hexDirections = []string{"e", "se", "sw", "w", "nw", "ne"}
+
+// Move steps a hex coordinate once in the given direction.
+// It will panic if dir is not one of "e", "se", "sw", "w", "nw" or "ne".
+// It returns the new HexCoordinate.
+func (h HexCoordinate) Move(dir string) HexCoordinate {
+	offset, ok := HexOffsets[dir]
+	if !ok {
+		panic(fmt.Sprintf("HexCoordinate.Move: unknown direction %q", dir))
+	}
+	return HexCoordinate{X: h.X + offset.X, Y: h.Y + offset.Y, Z: h.Z + offset.Z}
+}
+
+// Distance counts the fewest steps between two hex coordinates, (|dx| + |dy| + |dz|) / 2.
+// It returns an int.
+func (h HexCoordinate) Distance(o HexCoordinate) int {
+	return (AbsDiff(h.X, o.X) + AbsDiff(h.Y, o.Y) + AbsDiff(h.Z, o.Z)) / 2
+}
+
+// Neighbors collects the six hex coordinates adjacent to h, clockwise from east.
+// It returns a slice of HexCoordinates.
+func (h HexCoordinate) Neighbors() []HexCoordinate {
+	neighbors := make([]HexCoordinate, 0, len(hexDirections))
+	for _, dir := range hexDirections {
+		neighbors = append(neighbors, h.Move(dir))
 	}
+	return neighbors
 }
 
 // Trees
@@ -0,0 +1,1806 @@
+package aocutils
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// expectPanic fails the test if f returns without panicking, or if the panic message doesn't contain want.
+func expectPanic(t *testing.T, want string, f func()) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatalf("expected a panic containing %q, got none", want)
+		}
+		if msg := fmt.Sprint(r); !strings.Contains(msg, want) {
+			t.Fatalf("expected a panic containing %q, got %q", want, msg)
+		}
+	}()
+	f()
+}
+
+func TestStackInterleaved(t *testing.T) {
+	var s Stack[int]
+	s.Push(1, 2, 3)
+	if got := s.Pop(); got != 3 {
+		t.Fatalf("Pop() = %d, want 3", got)
+	}
+	s.Unshift(0)
+	s.Push(4)
+	if !slices.Equal(s, Stack[int]{0, 1, 2, 4}) {
+		t.Fatalf("stack = %v, want [0 1 2 4]", s)
+	}
+	if got := s.Shift(); got != 0 {
+		t.Fatalf("Shift() = %d, want 0", got)
+	}
+	if got := s.Shift(); got != 1 {
+		t.Fatalf("Shift() = %d, want 1", got)
+	}
+	s.Unshift(9)
+	if got := s.Pop(); got != 4 {
+		t.Fatalf("Pop() = %d, want 4", got)
+	}
+	if !slices.Equal(s, Stack[int]{9, 2}) {
+		t.Fatalf("stack = %v, want [9 2]", s)
+	}
+	if got := s.Pop(); got != 2 {
+		t.Fatalf("Pop() = %d, want 2", got)
+	}
+	if got := s.Shift(); got != 9 {
+		t.Fatalf("Shift() = %d, want 9", got)
+	}
+	if len(s) != 0 {
+		t.Fatalf("stack = %v, want empty", s)
+	}
+}
+
+func TestStackUnshiftOntoEmpty(t *testing.T) {
+	var s Stack[string]
+	s.Unshift("b")
+	s.Unshift("a")
+	s.Push("c")
+	if !slices.Equal(s, Stack[string]{"a", "b", "c"}) {
+		t.Fatalf("stack = %v, want [a b c]", s)
+	}
+}
+
+func TestStackEmptyPanics(t *testing.T) {
+	var s Stack[int]
+	expectPanic(t, "Stack.Pop: stack is empty", func() { s.Pop() })
+	expectPanic(t, "Stack.Shift: stack is empty", func() { s.Shift() })
+	s.Push(1)
+	s.Pop()
+	expectPanic(t, "Stack.Pop: stack is empty", func() { s.Pop() })
+}
+
+func TestQueueFIFO(t *testing.T) {
+	var q Queue[int]
+	if _, ok := q.Dequeue(); ok {
+		t.Fatal("Dequeue on an empty queue reported ok")
+	}
+	if _, ok := q.Peek(); ok {
+		t.Fatal("Peek on an empty queue reported ok")
+	}
+	next, want := 0, 0
+	for i := 0; i < 10000; i++ {
+		if i%3 == 2 {
+			got, ok := q.Dequeue()
+			if !ok || got != want {
+				t.Fatalf("Dequeue() = %d, %v, want %d, true", got, ok, want)
+			}
+			want++
+			continue
+		}
+		q.Enqueue(next)
+		next++
+	}
+	if q.Len() != next-want {
+		t.Fatalf("Len() = %d, want %d", q.Len(), next-want)
+	}
+	if got, ok := q.Peek(); !ok || got != want {
+		t.Fatalf("Peek() = %d, %v, want %d, true", got, ok, want)
+	}
+	for !q.IsEmpty() {
+		got, _ := q.Dequeue()
+		if got != want {
+			t.Fatalf("Dequeue() = %d, want %d", got, want)
+		}
+		want++
+	}
+	if want != next {
+		t.Fatalf("dequeued %d elements, want %d", want, next)
+	}
+}
+
+func TestQueueCompacts(t *testing.T) {
+	var q Queue[*int]
+	for i := range 1000 {
+		q.Enqueue(&i)
+	}
+	for range 999 {
+		q.Dequeue()
+	}
+	if q.Len() != 1 || len(q.items) > 2 {
+		t.Fatalf("queue holds %d slots for %d elements, want it compacted", len(q.items), q.Len())
+	}
+	for _, p := range q.items[len(q.items):cap(q.items)] {
+		if p != nil {
+			t.Fatal("queue still references a dequeued element")
+		}
+	}
+}
+
+// The benchmarks keep about a thousand elements queued, as a BFS frontier would,
+// while a million elements pass through.
+const queueBenchmarkOps = 1000000
+
+func BenchmarkQueue(b *testing.B) {
+	for range b.N {
+		var q Queue[int]
+		for i := range queueBenchmarkOps {
+			q.Enqueue(i)
+			if i >= 1000 {
+				q.Dequeue()
+			}
+		}
+	}
+}
+
+func BenchmarkStackShiftAsQueue(b *testing.B) {
+	for range b.N {
+		var s Stack[int]
+		for i := range queueBenchmarkOps {
+			s.Push(i)
+			if i >= 1000 {
+				s.Shift()
+			}
+		}
+	}
+}
+
+func ExamplePriorityQueue() {
+	// Sum the calories carried by the three elves carrying the most.
+	totals := []int{6000, 4000, 11000, 24000, 10000}
+	pq := NewPriorityQueue(func(a, b int) bool { return a > b })
+	pq.Push(totals...)
+	sum := 0
+	for range 3 {
+		total, _ := pq.Pop()
+		sum += total
+	}
+	fmt.Println(sum)
+	// Output: 45000
+}
+
+func ExamplePriorityQueue_dijkstra() {
+	// Find the lowest total risk from the top left to the bottom right of a grid.
+	grid := GridFromStrings([]string{
+		"1163751742",
+		"1381373672",
+		"2136511328",
+		"3694931569",
+		"7463417111",
+		"1319128137",
+		"1359912421",
+		"3125421639",
+		"1293138521",
+		"2311944581",
+	})
+	type state struct {
+		pos  Coordinate
+		risk int
+	}
+	end := Coordinate{X: 9, Y: 9}
+	pq := NewPriorityQueue(func(a, b state) bool { return a.risk < b.risk })
+	pq.Push(state{})
+	seen := make(map[Coordinate]bool)
+	for {
+		cur, ok := pq.Pop()
+		if !ok {
+			break
+		}
+		if seen[cur.pos] {
+			continue
+		}
+		seen[cur.pos] = true
+		if cur.pos == end {
+			fmt.Println(cur.risk)
+			break
+		}
+		for _, next := range grid.Neighbors4(cur.pos) {
+			pq.Push(state{next, cur.risk + RuneToDigit(grid[next.Y][next.X])})
+		}
+	}
+	// Output: 40
+}
+
+func TestPriorityQueueEmpty(t *testing.T) {
+	pq := NewPriorityQueue(func(a, b int) bool { return a < b })
+	if _, ok := pq.Pop(); ok {
+		t.Fatal("Pop on an empty queue reported ok")
+	}
+	if _, ok := pq.Peek(); ok {
+		t.Fatal("Peek on an empty queue reported ok")
+	}
+	pq.Push(5, 1, 3)
+	for _, want := range []int{1, 3, 5} {
+		if got, _ := pq.Pop(); got != want {
+			t.Fatalf("Pop() = %d, want %d", got, want)
+		}
+	}
+	if pq.Len() != 0 {
+		t.Fatalf("Len() = %d, want 0", pq.Len())
+	}
+}
+
+// checkIndexedHeap fails the test unless every key's recorded index points at its entry
+// and every entry is ordered no earlier than its parent.
+func checkIndexedHeap[K comparable, P any](t *testing.T, pq *IndexedPriorityQueue[K, P]) {
+	t.Helper()
+	h := pq.h
+	if len(h.index) != len(h.entries) {
+		t.Fatalf("index has %d keys for %d entries", len(h.index), len(h.entries))
+	}
+	for i, entry := range h.entries {
+		if j, ok := h.index[entry.key]; !ok || j != i {
+			t.Fatalf("key %v is at %d but indexed at %d (present %v)", entry.key, i, j, ok)
+		}
+		if i > 0 && h.less(entry.priority, h.entries[(i-1)/2].priority) {
+			t.Fatalf("entry %d orders before its parent", i)
+		}
+	}
+}
+
+func TestIndexedPriorityQueueRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	pq := NewIndexedPriorityQueue[int](func(a, b int) bool { return a < b })
+	want := make(map[int]int)
+	for range 20000 {
+		key, priority := r.Intn(200), r.Intn(1000)
+		switch {
+		case r.Intn(4) == 0 && pq.Len() > 0:
+			gotKey, gotPriority := pq.PopMin()
+			for k, p := range want {
+				if p < gotPriority {
+					t.Fatalf("PopMin() = %d (%d), but %d has lower priority %d", gotKey, gotPriority, k, p)
+				}
+			}
+			if want[gotKey] != gotPriority {
+				t.Fatalf("PopMin() = %d (%d), want priority %d", gotKey, gotPriority, want[gotKey])
+			}
+			delete(want, gotKey)
+		case pq.Contains(key):
+			pq.UpdatePriority(key, priority)
+			want[key] = priority
+		default:
+			pq.Insert(key, priority)
+			want[key] = priority
+		}
+		checkIndexedHeap(t, pq)
+		if got, ok := pq.Priority(key); ok != pq.Contains(key) || (ok && got != want[key]) {
+			t.Fatalf("Priority(%d) = %d, %v, want %d", key, got, ok, want[key])
+		}
+	}
+	for pq.Len() > 0 {
+		key, _ := pq.PopMin()
+		if pq.Contains(key) {
+			t.Fatalf("popped key %d is still queued", key)
+		}
+		checkIndexedHeap(t, pq)
+	}
+}
+
+func TestIndexedPriorityQueueUpdateBothWays(t *testing.T) {
+	pq := NewIndexedPriorityQueue[string](func(a, b int) bool { return a < b })
+	pq.Insert("a", 5)
+	pq.Insert("b", 3)
+	pq.Insert("c", 4)
+	pq.UpdatePriority("a", 1)
+	pq.UpdatePriority("b", 9)
+	checkIndexedHeap(t, pq)
+	var order []string
+	for pq.Len() > 0 {
+		key, _ := pq.PopMin()
+		order = append(order, key)
+	}
+	if !slices.Equal(order, []string{"a", "c", "b"}) {
+		t.Fatalf("pop order = %v, want [a c b]", order)
+	}
+}
+
+func TestIndexedPriorityQueuePanics(t *testing.T) {
+	pq := NewIndexedPriorityQueue[string](func(a, b int) bool { return a < b })
+	expectPanic(t, "queue is empty", func() { pq.PopMin() })
+	expectPanic(t, "is not queued", func() { pq.UpdatePriority("x", 1) })
+	pq.Insert("x", 1)
+	expectPanic(t, "is already queued", func() { pq.Insert("x", 2) })
+}
+
+// dequeContents reads every element of a deque from front to back using At.
+func dequeContents[T any](d *Deque[T]) []T {
+	out := make([]T, 0, d.Len())
+	for i := range d.Len() {
+		out = append(out, d.At(i))
+	}
+	return out
+}
+
+func TestDequeGrowAcrossWrap(t *testing.T) {
+	var d Deque[int]
+	for i := range 4 {
+		d.PushBack(i)
+	}
+	d.PopFront()
+	d.PopFront()
+	d.PushBack(4)
+	d.PushBack(5)
+	if d.head == 0 || d.head+d.size <= len(d.buf) {
+		t.Fatalf("deque did not wrap: head %d, size %d, capacity %d", d.head, d.size, len(d.buf))
+	}
+	if got := dequeContents(&d); !slices.Equal(got, []int{2, 3, 4, 5}) {
+		t.Fatalf("wrapped deque = %v, want [2 3 4 5]", got)
+	}
+	d.PushBack(6)
+	if got := dequeContents(&d); !slices.Equal(got, []int{2, 3, 4, 5, 6}) {
+		t.Fatalf("deque after growing = %v, want [2 3 4 5 6]", got)
+	}
+}
+
+func TestDequePushFrontWraps(t *testing.T) {
+	var d Deque[int]
+	d.PushBack(1)
+	d.PushBack(2)
+	d.PushFront(0)
+	d.PushFront(-1)
+	d.PushFront(-2)
+	if got := dequeContents(&d); !slices.Equal(got, []int{-2, -1, 0, 1, 2}) {
+		t.Fatalf("deque = %v, want [-2 -1 0 1 2]", got)
+	}
+	if front, _ := d.PeekFront(); front != -2 {
+		t.Fatalf("PeekFront() = %d, want -2", front)
+	}
+	if back, _ := d.PeekBack(); back != 2 {
+		t.Fatalf("PeekBack() = %d, want 2", back)
+	}
+}
+
+func TestDequeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var d Deque[int]
+	want := make([]int, 0)
+	for i := range 5000 {
+		switch r.Intn(4) {
+		case 0:
+			d.PushFront(i)
+			want = append([]int{i}, want...)
+		case 1:
+			d.PushBack(i)
+			want = append(want, i)
+		case 2:
+			got, ok := d.PopFront()
+			if ok != (len(want) > 0) || (ok && got != want[0]) {
+				t.Fatalf("PopFront() = %d, %v, want front of %v", got, ok, want)
+			}
+			if ok {
+				want = want[1:]
+			}
+		case 3:
+			got, ok := d.PopBack()
+			if ok != (len(want) > 0) || (ok && got != want[len(want)-1]) {
+				t.Fatalf("PopBack() = %d, %v, want back of %v", got, ok, want)
+			}
+			if ok {
+				want = want[:len(want)-1]
+			}
+		}
+		if got := dequeContents(&d); !slices.Equal(got, want) {
+			t.Fatalf("deque = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDequeAtPanics(t *testing.T) {
+	var d Deque[int]
+	expectPanic(t, "index 0 out of range for length 0", func() { d.At(0) })
+	d.PushBack(1)
+	expectPanic(t, "index -1 out of range", func() { d.At(-1) })
+	expectPanic(t, "index 1 out of range", func() { d.At(1) })
+}
+
+func TestBaseRoundTrip(t *testing.T) {
+	alphabets := []string{"01", "01234567", "0123456789", "0123456789abcdef", "abcdefghijklmnopqrstuvwxyz", "xy"}
+	nums := []int{0, 1, 2, 7, 25, 26, 255, 1000, 123456789, math.MaxInt}
+	for _, digits := range alphabets {
+		for _, n := range nums {
+			s := FormatBase(n, digits)
+			if got := ParseBase(s, digits); got != n {
+				t.Errorf("ParseBase(FormatBase(%d, %q)) = ParseBase(%q) = %d", n, digits, s, got)
+			}
+		}
+	}
+	if got := FormatBase(255, "0123456789abcdef"); got != "ff" {
+		t.Errorf("FormatBase(255, hex) = %q, want \"ff\"", got)
+	}
+	if got := ParseBase("ba", "ab"); got != 2 {
+		t.Errorf("ParseBase(\"ba\", \"ab\") = %d, want 2", got)
+	}
+}
+
+func TestBaseMapSNAFU(t *testing.T) {
+	snafu := map[rune]int{'=': -2, '-': -1, '0': 0, '1': 1, '2': 2}
+	cases := map[int]string{
+		0: "0", 1: "1", 3: "1=", 8: "2=", 2022: "1=11-2", 12345: "1-0---0",
+		314159265: "1121-1110-1=0", 4890: "2=-1=0", -1: "-", -3: "-2",
+	}
+	for n, want := range cases {
+		if got := FormatBaseMap(n, snafu, 5); got != want {
+			t.Errorf("FormatBaseMap(%d) = %q, want %q", n, got, want)
+		}
+		if got := ParseBaseMap(want, snafu, 5); got != n {
+			t.Errorf("ParseBaseMap(%q) = %d, want %d", want, got, n)
+		}
+	}
+	for _, n := range []int{math.MaxInt, math.MinInt + 1} {
+		if got := ParseBaseMap(FormatBaseMap(n, snafu, 5), snafu, 5); got != n {
+			t.Errorf("SNAFU round trip of %d gave %d", n, got)
+		}
+	}
+}
+
+func TestFormatBaseMapUnrepresentable(t *testing.T) {
+	decimal := make(map[rune]int)
+	for i, r := range "0123456789" {
+		decimal[r] = i
+	}
+	expectPanic(t, "without a negative digit", func() { FormatBaseMap(-1, decimal, 10) })
+	odd := map[rune]int{'a': 0, 'b': 1, 'c': 5}
+	expectPanic(t, "cannot represent", func() { FormatBaseMap(2, odd, 3) })
+}
+
+func TestHexToBinStr(t *testing.T) {
+	cases := map[string]string{
+		"0F":     "00001111",
+		"00":     "00000000",
+		"D2FE28": "110100101111111000101000",
+		"a":      "1010",
+		"":       "",
+	}
+	for hex, want := range cases {
+		got, err := HexToBinStr(hex)
+		if err != nil || got != want {
+			t.Errorf("HexToBinStr(%q) = %q, %v, want %q", hex, got, err, want)
+		}
+	}
+	if _, err := HexToBinStr("1G"); err == nil || !strings.Contains(err.Error(), "'G' at index 1") {
+		t.Errorf("HexToBinStr(\"1G\") error = %v, want one naming 'G' at index 1", err)
+	}
+}
+
+func TestBinStrToHex(t *testing.T) {
+	cases := map[string]string{
+		"00001111":                 "0F",
+		"110100101111111000101000": "D2FE28",
+		"":                         "",
+	}
+	for bin, want := range cases {
+		got, err := BinStrToHex(bin)
+		if err != nil || got != want {
+			t.Errorf("BinStrToHex(%q) = %q, %v, want %q", bin, got, err, want)
+		}
+	}
+	if _, err := BinStrToHex("101"); err == nil {
+		t.Error("BinStrToHex(\"101\") returned no error for a length that isn't a multiple of 4")
+	}
+	if _, err := BinStrToHex("0102"); err == nil || !strings.Contains(err.Error(), "'2' at index 3") {
+		t.Errorf("BinStrToHex(\"0102\") error = %v, want one naming '2' at index 3", err)
+	}
+}
+
+func TestTopoSortTieBreaking(t *testing.T) {
+	got, err := TopoSort([]string{"p", "q", "a", "b"}, map[string][]string{"p": {"b"}, "q": {"a"}})
+	if err != nil || !slices.Equal(got, []string{"p", "q", "a", "b"}) {
+		t.Fatalf("TopoSort = %v, %v, want [p q a b]", got, err)
+	}
+	steps := map[string][]string{"C": {"A", "F"}, "A": {"B", "D"}, "B": {"E"}, "D": {"E"}, "F": {"E"}}
+	got, err = TopoSortStable([]string{"A", "B", "C", "D", "E", "F"}, steps, func(a, b string) bool { return a < b })
+	if err != nil || strings.Join(got, "") != "CABDFE" {
+		t.Fatalf("TopoSortStable = %v, %v, want CABDFE", got, err)
+	}
+	if _, err := TopoSort([]int{1, 2, 3}, map[int][]int{1: {2}, 2: {3}, 3: {2}}); err == nil {
+		t.Fatal("TopoSort returned no error for a cycle")
+	}
+}
+
+func TestConcatInts(t *testing.T) {
+	cases := []struct{ a, b, want int }{
+		{12, 345, 12345},
+		{1, 0, 10},
+		{0, 7, 7},
+		{10, 10, 1010},
+		{9, 99, 999},
+		{922337203685477580, 7, math.MaxInt},
+	}
+	for _, c := range cases {
+		if got := ConcatInts(c.a, c.b); got != c.want {
+			t.Errorf("ConcatInts(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+	expectPanic(t, "overflows int", func() { ConcatInts(922337203685477580, 8) })
+	expectPanic(t, "overflows int", func() { ConcatInts(1, math.MaxInt) })
+	expectPanic(t, "must be non-negative", func() { ConcatInts(1, -2) })
+}
+
+func BenchmarkConcatInts(b *testing.B) {
+	for i := range b.N {
+		ConcatInts(i%100000, 12345)
+	}
+}
+
+func BenchmarkConcatIntsSprintf(b *testing.B) {
+	for i := range b.N {
+		n, _ := strconv.Atoi(fmt.Sprintf("%d%d", i%100000, 12345))
+		_ = n
+	}
+}
+
+func TestPow(t *testing.T) {
+	cases := []struct{ n, m, want int }{
+		{0, 0, 1},
+		{5, 0, 1},
+		{-7, 0, 1},
+		{0, 1, 0},
+		{5, 1, 5},
+		{-7, 1, -7},
+		{0, 9, 0},
+		{2, 10, 1024},
+		{-3, 3, -27},
+		{1, math.MaxInt, 1},
+		{-1, math.MaxInt, -1},
+		{-1, 1 << 40, 1},
+		{2, 62, 1 << 62},
+		{-2, 63, math.MinInt},
+		{3, 39, 4052555153018976267},
+		{10, 18, 1000000000000000000},
+	}
+	for _, c := range cases {
+		if got := Pow(c.n, c.m); got != c.want {
+			t.Errorf("Pow(%d, %d) = %d, want %d", c.n, c.m, got, c.want)
+		}
+	}
+	expectPanic(t, "negative exponent -3", func() { Pow(2, -3) })
+	expectPanic(t, "negative exponent", func() { Pow(1, -1) })
+	expectPanic(t, "overflow", func() { Pow(2, 63) })
+	expectPanic(t, "overflow", func() { Pow(10, 19) })
+	expectPanic(t, "overflow", func() { Pow(3037000500, 2) })
+}
+
+func TestAddOverflows(t *testing.T) {
+	cases := []struct {
+		a, b     int
+		overflow bool
+	}{
+		{math.MaxInt, 0, false},
+		{math.MaxInt, 1, true},
+		{math.MaxInt - 1, 1, false},
+		{math.MaxInt, math.MaxInt, true},
+		{math.MinInt, 0, false},
+		{math.MinInt, -1, true},
+		{math.MinInt + 1, -1, false},
+		{math.MinInt, math.MinInt, true},
+		{math.MaxInt, math.MinInt, false},
+		{math.MinInt, math.MaxInt, false},
+		{-5, 3, false},
+	}
+	for _, c := range cases {
+		sum, overflow := AddOverflows(c.a, c.b)
+		if overflow != c.overflow {
+			t.Errorf("AddOverflows(%d, %d) overflow = %v, want %v", c.a, c.b, overflow, c.overflow)
+		}
+		if !overflow && sum != c.a+c.b {
+			t.Errorf("AddOverflows(%d, %d) = %d, want %d", c.a, c.b, sum, c.a+c.b)
+		}
+	}
+}
+
+func TestMulOverflows(t *testing.T) {
+	cases := []struct {
+		a, b     int
+		overflow bool
+	}{
+		{math.MaxInt, 1, false},
+		{math.MaxInt, -1, false},
+		{math.MaxInt, 2, true},
+		{math.MaxInt, -2, true},
+		{math.MinInt, 1, false},
+		{math.MinInt, -1, true},
+		{-1, math.MinInt, true},
+		{math.MinInt, 2, true},
+		{math.MinInt / 2, 2, false},
+		{math.MinInt / 2, -2, true},
+		{math.MaxInt / 2, 2, false},
+		{math.MaxInt/2 + 1, 2, true},
+		{3037000499, 3037000499, false},
+		{3037000500, 3037000500, true},
+		{-3037000500, 3037000500, true},
+		{0, math.MinInt, false},
+		{math.MaxInt, 0, false},
+	}
+	for _, c := range cases {
+		product, overflow := MulOverflows(c.a, c.b)
+		if overflow != c.overflow {
+			t.Errorf("MulOverflows(%d, %d) overflow = %v, want %v", c.a, c.b, overflow, c.overflow)
+		}
+		if !overflow && product != c.a*c.b {
+			t.Errorf("MulOverflows(%d, %d) = %d, want %d", c.a, c.b, product, c.a*c.b)
+		}
+	}
+}
+
+func TestCheckedPanics(t *testing.T) {
+	expectPanic(t, fmt.Sprintf("AddChecked: %d + 1 overflows int", math.MaxInt), func() { AddChecked(math.MaxInt, 1) })
+	expectPanic(t, fmt.Sprintf("MulChecked: %d * -1 overflows int", math.MinInt), func() { MulChecked(math.MinInt, -1) })
+	if got := AddChecked(math.MinInt, math.MaxInt); got != -1 {
+		t.Errorf("AddChecked(MinInt, MaxInt) = %d, want -1", got)
+	}
+	if got := MulChecked(math.MinInt/2, 2); got != math.MinInt {
+		t.Errorf("MulChecked(MinInt/2, 2) = %d, want MinInt", got)
+	}
+}
+
+// boolSieve is the naive sieve of Eratosthenes with one bool per number, for comparison with PrimesUpTo.
+func boolSieve(n int) []int {
+	primes := make([]int, 0)
+	composite := make([]bool, n+1)
+	for i := 2; i <= n; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, i)
+		for j := i * i; j <= n; j += i {
+			composite[j] = true
+		}
+	}
+	return primes
+}
+
+func TestPrimesUpTo(t *testing.T) {
+	for _, n := range []int{-1, 0, 1, 2, 3, 4, 10, 97, 100, 10000} {
+		if got, want := PrimesUpTo(n), boolSieve(n); !slices.Equal(got, want) {
+			t.Errorf("PrimesUpTo(%d) = %v, want %v", n, got, want)
+		}
+	}
+	i := 0
+	want := boolSieve(10000)
+	for p := range Primes() {
+		if i == len(want) {
+			break
+		}
+		if p != want[i] {
+			t.Fatalf("Primes() item %d = %d, want %d", i, p, want[i])
+		}
+		i++
+	}
+	for _, p := range want {
+		if !IsPrime(p) {
+			t.Errorf("IsPrime(%d) = false", p)
+		}
+	}
+	for _, n := range []int{1, 4, 561, 1 << 20, 1000000007 * 998244353, 3215031751} {
+		if IsPrime(n) {
+			t.Errorf("IsPrime(%d) = true", n)
+		}
+	}
+	for _, n := range []int{1000000007, 998244353, 2305843009213693951} {
+		if !IsPrime(n) {
+			t.Errorf("IsPrime(%d) = false", n)
+		}
+	}
+}
+
+const sieveBenchmarkLimit = 10000000
+
+func BenchmarkPrimesUpTo(b *testing.B) {
+	for range b.N {
+		PrimesUpTo(sieveBenchmarkLimit)
+	}
+}
+
+func BenchmarkBoolSieve(b *testing.B) {
+	for range b.N {
+		boolSieve(sieveBenchmarkLimit)
+	}
+}
+
+// sumDivisorsByEnumeration adds up the divisors of n found by trial division up to its square root,
+// for comparison with SumDivisors.
+func sumDivisorsByEnumeration(n int) int {
+	sum := 0
+	for i := 1; i*i <= n; i++ {
+		if n%i == 0 {
+			sum += i
+			if i != n/i {
+				sum += n / i
+			}
+		}
+	}
+	return sum
+}
+
+func TestDivisors(t *testing.T) {
+	if got := Divisors(1); !slices.Equal(got, []int{1}) {
+		t.Errorf("Divisors(1) = %v, want [1]", got)
+	}
+	if got := Divisors(36); !slices.Equal(got, []int{1, 2, 3, 4, 6, 9, 12, 18, 36}) {
+		t.Errorf("Divisors(36) = %v", got)
+	}
+	for _, n := range []int{1, 2, 12, 36, 49, 97, 360, 1000000, 999999937, 735134400} {
+		divisors := Divisors(n)
+		if got := CountDivisors(n); got != len(divisors) {
+			t.Errorf("CountDivisors(%d) = %d, want %d", n, got, len(divisors))
+		}
+		if got, want := SumDivisors(n), sumDivisorsByEnumeration(n); got != want || got != Sum(divisors) {
+			t.Errorf("SumDivisors(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+// Numbers near 1e9 for comparing SumDivisors with enumerating every divisor.
+var divisorBenchmarkInputs = []int{735134400, 963761198, 997920000, 999999000}
+
+func BenchmarkSumDivisors(b *testing.B) {
+	for i := range b.N {
+		SumDivisors(divisorBenchmarkInputs[i%len(divisorBenchmarkInputs)])
+	}
+}
+
+func BenchmarkSumDivisorsByEnumeration(b *testing.B) {
+	for i := range b.N {
+		sumDivisorsByEnumeration(divisorBenchmarkInputs[i%len(divisorBenchmarkInputs)])
+	}
+}
+
+func TestCRT(t *testing.T) {
+	// Bus IDs 7,13,x,x,59,x,31,19: bus b at offset i departs at t+i, so t ≡ -i (mod b).
+	cases := []struct {
+		schedule []int
+		want     int
+	}{
+		{[]int{7, 13, 0, 0, 59, 0, 31, 19}, 1068781},
+		{[]int{17, 0, 13, 19}, 3417},
+		{[]int{67, 7, 59, 61}, 754018},
+		{[]int{1789, 37, 47, 1889}, 1202161486},
+	}
+	for _, c := range cases {
+		var remainders, moduli []int
+		for i, bus := range c.schedule {
+			if bus != 0 {
+				remainders = append(remainders, -i)
+				moduli = append(moduli, bus)
+			}
+		}
+		got, modulus, err := CRT(remainders, moduli)
+		if err != nil || got != c.want || modulus != Product(moduli) {
+			t.Errorf("CRT for %v = %d, %d, %v, want %d, %d", c.schedule, got, modulus, err, c.want, Product(moduli))
+		}
+	}
+	if got, modulus, err := CRT([]int{2, 4}, []int{6, 8}); err != nil || got != 20 || modulus != 24 {
+		t.Errorf("CRT with non-coprime moduli = %d, %d, %v, want 20, 24", got, modulus, err)
+	}
+	if _, _, err := CRT([]int{1, 2}, []int{4, 6}); err == nil {
+		t.Error("CRT returned no error for an inconsistent system")
+	}
+	if _, _, err := CRT([]int{1}, []int{4, 6}); err == nil {
+		t.Error("CRT returned no error for mismatched lengths")
+	}
+	if _, _, err := CRT([]int{1}, []int{0}); err == nil {
+		t.Error("CRT returned no error for a zero modulus")
+	}
+}
+
+func TestTriangular(t *testing.T) {
+	for n, want := range []int{0, 1, 3, 6, 10, 15} {
+		if got := Triangular(n); got != want {
+			t.Errorf("Triangular(%d) = %d, want %d", n, got, want)
+		}
+	}
+	if got := Triangular(4294967295); got != 9223372034707292160 {
+		t.Errorf("Triangular(4294967295) = %d, want 9223372034707292160", got)
+	}
+	expectPanic(t, "overflows int", func() { Triangular(4294967296) })
+	expectPanic(t, "must be non-negative", func() { Triangular(-1) })
+}
+
+func TestInverseTriangular(t *testing.T) {
+	cases := []struct {
+		t, n int
+		ok   bool
+	}{
+		{0, 0, true},
+		{1, 1, true},
+		{2, 1, false},
+		{3, 2, true},
+		{5050, 100, true},
+		{5051, 100, false},
+		{5049, 99, false},
+		{-4, 0, false},
+		{9223372034707292160, 4294967295, true},
+		{9223372034707292159, 4294967294, false},
+		{math.MaxInt, 4294967295, false},
+	}
+	for _, c := range cases {
+		if n, ok := InverseTriangular(c.t); n != c.n || ok != c.ok {
+			t.Errorf("InverseTriangular(%d) = %d, %v, want %d, %v", c.t, n, ok, c.n, c.ok)
+		}
+	}
+	for n := range 2000 {
+		if got, ok := InverseTriangular(Triangular(n)); got != n || !ok {
+			t.Fatalf("InverseTriangular(Triangular(%d)) = %d, %v", n, got, ok)
+		}
+	}
+}
+
+func TestSumRange(t *testing.T) {
+	cases := []struct{ lo, hi, want int }{
+		{1, 100, 5050},
+		{5, 5, 5},
+		{6, 5, 0},
+		{-3, 5, 9},
+		{-5, 3, -9},
+		{-3, 3, 0},
+		{-3, 0, -6},
+		{0, 3, 6},
+		{-10, -1, -55},
+		{math.MinInt, math.MaxInt, math.MinInt},
+		{-math.MaxInt, math.MaxInt, 0},
+		{math.MinInt + 1, math.MaxInt, 0},
+		{math.MinInt, math.MinInt, math.MinInt},
+		{math.MaxInt, math.MaxInt, math.MaxInt},
+		{math.MinInt / 2, math.MinInt/2 + 1, math.MinInt + 1},
+		{math.MaxInt / 2, math.MaxInt/2 + 1, math.MaxInt},
+	}
+	for _, c := range cases {
+		if got := SumRange(c.lo, c.hi); got != c.want {
+			t.Errorf("SumRange(%d, %d) = %d, want %d", c.lo, c.hi, got, c.want)
+		}
+	}
+	for lo := -30; lo <= 30; lo++ {
+		for hi := lo; hi <= 30; hi++ {
+			want := 0
+			for i := lo; i <= hi; i++ {
+				want += i
+			}
+			if got := SumRange(lo, hi); got != want {
+				t.Fatalf("SumRange(%d, %d) = %d, want %d", lo, hi, got, want)
+			}
+		}
+	}
+	expectPanic(t, "overflows int", func() { SumRange(math.MaxInt-1, math.MaxInt) })
+	expectPanic(t, "overflows int", func() { SumRange(math.MinInt, -1) })
+	expectPanic(t, "overflows int", func() { SumRange(0, math.MaxInt) })
+}
+
+// The states 0, 1 and 2 lead into the cycle 3, 4, ..., 9, 3, ..., so mu is 3 and lambda is 7.
+func cycleStep(x int) int {
+	if x < 9 {
+		return x + 1
+	}
+	return 3
+}
+
+// cycleStateAfter returns the state of cycleStep after n iterations from 0, worked out by hand.
+func cycleStateAfter(n int) int {
+	if n < 3 {
+		return n
+	}
+	return 3 + (n-3)%7
+}
+
+func TestFindCycleN(t *testing.T) {
+	start, length, found := FindCycleN(0, cycleStep, 100)
+	if start != 3 || length != 7 || !found {
+		t.Fatalf("FindCycleN = %d, %d, %v, want 3, 7, true", start, length, found)
+	}
+	// The repeat is only seen once step has been applied start+length times.
+	if _, _, found := FindCycleN(0, cycleStep, 9); found {
+		t.Error("FindCycleN found a cycle within 9 steps, want it to need 10")
+	}
+	if start, length, found := FindCycleN(0, cycleStep, 10); start != 3 || length != 7 || !found {
+		t.Errorf("FindCycleN with 10 steps = %d, %d, %v, want 3, 7, true", start, length, found)
+	}
+	if _, _, found := FindCycleN(0, func(x int) int { return x + 1 }, 1000); found {
+		t.Error("FindCycleN found a cycle in a sequence that never repeats")
+	}
+	if start, length := FindCycle(0, cycleStep); start != 3 || length != 7 {
+		t.Errorf("FindCycle = %d, %d, want 3, 7", start, length)
+	}
+}
+
+func TestCycleExtrapolation(t *testing.T) {
+	const huge = 1000000000000000000
+	targets := []int{0, 1, 2, 3, 4, 9, 10, 11, 16, 17, 100, huge, huge + 1, huge + 2}
+	start, length, _ := FindCycleN(0, cycleStep, 1000)
+	for _, target := range targets {
+		want := cycleStateAfter(target)
+		if target < 100 {
+			simulated := 0
+			for range target {
+				simulated = cycleStep(simulated)
+			}
+			if simulated != want {
+				t.Fatalf("test oracle disagrees with simulation at %d: %d vs %d", target, want, simulated)
+			}
+		}
+		equivalent := target
+		if target >= start {
+			equivalent = start + (target-start)%length
+		}
+		if got := cycleStateAfter(equivalent); got != want {
+			t.Errorf("FindCycleN extrapolates %d to iteration %d with state %d, want state %d", target, equivalent, got, want)
+		}
+		if got := IterateN(0, cycleStep, target); got != want {
+			t.Errorf("IterateN(%d) = %d, want %d", target, got, want)
+		}
+		state := 0
+		iteration := FindCycleKeyed(func() { state = cycleStep(state) }, func() string { return strconv.Itoa(state) }, target)
+		if state != want || cycleStateAfter(iteration) != want || iteration > target {
+			t.Errorf("FindCycleKeyed(%d) = %d leaving state %d, want state %d", target, iteration, state, want)
+		}
+	}
+}
+
+func TestMatPowFibonacci(t *testing.T) {
+	fib := []int{0, 1}
+	for len(fib) <= 92 {
+		fib = append(fib, fib[len(fib)-1]+fib[len(fib)-2])
+	}
+	step := Matrix{{1, 1}, {1, 0}}
+	for n := 0; n < 92; n++ {
+		m := MatPow(step, n)
+		if m[0][1] != fib[n] || m[0][0] != fib[n+1] {
+			t.Fatalf("MatPow([[1 1] [1 0]], %d) = %v, want F(%d) = %d", n, m, n, fib[n])
+		}
+	}
+	const mod = 1000000007
+	for _, n := range []int{0, 1, 10, 50, 91} {
+		if got := MatPowMod(step, n, mod)[0][1]; got != fib[n]%mod {
+			t.Errorf("MatPowMod F(%d) = %d, want %d", n, got, fib[n]%mod)
+		}
+	}
+	// F(10^18) mod 1e9+7, computed independently with fast doubling.
+	if got := MatPowMod(step, 1000000000000000000, mod)[0][1]; got != 209783453 {
+		t.Errorf("MatPowMod F(1e18) = %d, want 209783453", got)
+	}
+}
+
+func TestMatMulDimensions(t *testing.T) {
+	a := Matrix{{1, 2, 3}, {4, 5, 6}}
+	b := Matrix{{7, 8}, {9, 10}, {11, 12}}
+	if got := MatMul(a, b); !slices.EqualFunc(got, Matrix{{58, 64}, {139, 154}}, slices.Equal) {
+		t.Errorf("MatMul = %v, want [[58 64] [139 154]]", got)
+	}
+	expectPanic(t, "cannot multiply 2x3 by 0x0 matrix", func() { MatMul(a, Matrix{}) })
+	expectPanic(t, "cannot multiply 2x3 by 2x3 matrix", func() { MatMul(a, a) })
+	expectPanic(t, "row 1 has 1 columns, expected 2", func() { MatMul(Matrix{{1, 2}, {3}}, b) })
+	expectPanic(t, "row 2 has 1 columns, expected 2", func() { MatMul(a, Matrix{{1, 2}, {3, 4}, {5}}) })
+	expectPanic(t, "is not square", func() { MatPow(a, 2) })
+	expectPanic(t, "row 1 has 1 columns", func() { MatPow(Matrix{{1, 2}, {3}}, 2) })
+}
+
+// lagoonSize digs a trench by following each direction and distance, as in 2023 day 18,
+// and counts the cells inside or on it using ShoelaceArea, PerimeterLength and PicksInteriorPoints.
+func lagoonSize(dirs []rune, dists []int) int {
+	offsets := map[rune]Coordinate{'R': Offsets[E], 'D': Offsets[S], 'L': Offsets[W], 'U': Offsets[N]}
+	pos := Coordinate{}
+	vertices := make([]Coordinate, 0, len(dirs))
+	for i, dir := range dirs {
+		pos = Coordinate{X: pos.X + offsets[dir].X*dists[i], Y: pos.Y + offsets[dir].Y*dists[i]}
+		vertices = append(vertices, pos)
+	}
+	boundary := PerimeterLength(vertices)
+	return PicksInteriorPoints(ShoelaceArea(vertices), boundary) + boundary
+}
+
+func TestLagoonArea(t *testing.T) {
+	plan := []string{
+		"R 6 (#70c710)", "D 5 (#0dc571)", "L 2 (#5713f0)", "D 2 (#d2c081)", "R 2 (#59c680)",
+		"D 2 (#411b91)", "L 5 (#8ceee2)", "U 2 (#caa173)", "L 1 (#1b58a2)", "U 2 (#caa171)",
+		"R 2 (#7807d2)", "U 3 (#a77fa3)", "L 2 (#015232)", "U 2 (#7a21e3)",
+	}
+	var dirs, hexDirs []rune
+	var dists, hexDists []int
+	for _, line := range plan {
+		fields := MustParse(line, "%s %d (#%s)")
+		dirs = append(dirs, rune(fields[0][0]))
+		dists = append(dists, StrToInt(fields[1]))
+		hexDirs = append(hexDirs, rune("RDLU"[fields[2][5]-'0']))
+		hexDists = append(hexDists, ParseBase(strings.ToLower(fields[2][:5]), "0123456789abcdef"))
+	}
+	if got := lagoonSize(dirs, dists); got != 62 {
+		t.Errorf("lagoon size = %d, want 62", got)
+	}
+	if got := lagoonSize(hexDirs, hexDists); got != 952408144115 {
+		t.Errorf("lagoon size from hex = %d, want 952408144115", got)
+	}
+	// Reversing the vertices, so they run the other way around, must give the same area.
+	slices.Reverse(dirs)
+	slices.Reverse(dists)
+	for i, dir := range dirs {
+		dirs[i] = map[rune]rune{'R': 'L', 'L': 'R', 'U': 'D', 'D': 'U'}[dir]
+	}
+	if got := lagoonSize(dirs, dists); got != 62 {
+		t.Errorf("reversed lagoon size = %d, want 62", got)
+	}
+}
+
+func TestPipeLoopEnclosed(t *testing.T) {
+	connections := map[rune][]Coordinate{
+		'|': {Offsets[N], Offsets[S]}, '-': {Offsets[W], Offsets[E]},
+		'L': {Offsets[N], Offsets[E]}, 'J': {Offsets[N], Offsets[W]},
+		'7': {Offsets[S], Offsets[W]}, 'F': {Offsets[S], Offsets[E]},
+	}
+	follow := func(g Grid[rune], prev, cur Coordinate) (Coordinate, bool) {
+		for _, d := range connections[g[cur.Y][cur.X]] {
+			if next := (Coordinate{X: cur.X + d.X, Y: cur.Y + d.Y}); next != prev {
+				return next, true
+			}
+		}
+		return cur, false
+	}
+	cases := []struct {
+		lines         []string
+		start         Coordinate
+		startPipe     rune
+		farthest      int
+		enclosedTiles int
+	}{
+		{[]string{"-L|F7", "7S-7|", "L|7||", "-L-J|", "L|-JF"}, Coordinate{X: 1, Y: 1}, 'F', 4, 1},
+		{[]string{
+			"...........",
+			".S-------7.",
+			".|F-----7|.",
+			".||.....||.",
+			".||.....||.",
+			".|L-7.F-J|.",
+			".|..|.|..|.",
+			".L--J.L--J.",
+			"...........",
+		}, Coordinate{X: 1, Y: 1}, 'F', 23, 4},
+	}
+	for _, c := range cases {
+		g := GridFromStrings(c.lines)
+		g[c.start.Y][c.start.X] = c.startPipe
+		loop := TracePath(g, c.start, follow)
+		if got := len(loop) / 2; got != c.farthest {
+			t.Errorf("farthest point = %d, want %d", got, c.farthest)
+		}
+		if got := PicksInteriorPoints(ShoelaceArea(loop), len(loop)); got != c.enclosedTiles {
+			t.Errorf("enclosed tiles = %d, want %d", got, c.enclosedTiles)
+		}
+	}
+}
+
+func TestDivModEuclid(t *testing.T) {
+	cases := []struct{ a, b, q, r int }{
+		{7, 2, 3, 1},
+		{-7, 2, -4, 1},
+		{7, -2, -3, 1},
+		{-7, -2, 4, 1},
+		{-6, 3, -2, 0},
+		{0, 5, 0, 0},
+		{math.MinInt, 3, -3074457345618258603, 1},
+		{math.MinInt, -3, 3074457345618258603, 1},
+		{math.MinInt, 2, math.MinInt / 2, 0},
+		{math.MinInt, math.MaxInt, -2, math.MaxInt - 1},
+		{math.MaxInt, math.MinInt, 0, math.MaxInt},
+		{math.MinInt, math.MinInt, 1, 0},
+		{-1, math.MinInt, 1, math.MaxInt},
+		{math.MaxInt, 2, math.MaxInt / 2, 1},
+	}
+	for _, c := range cases {
+		q, r := DivModEuclid(c.a, c.b)
+		if q != c.q || r != c.r {
+			t.Errorf("DivModEuclid(%d, %d) = %d, %d, want %d, %d", c.a, c.b, q, r, c.q, c.r)
+		}
+		if r != Mod(c.a, c.b) || q*c.b+r != c.a {
+			t.Errorf("DivModEuclid(%d, %d) = %d, %d does not satisfy q*b + r == a with r == Mod(a, b)", c.a, c.b, q, r)
+		}
+	}
+	expectPanic(t, "division by zero", func() { DivModEuclid(1, 0) })
+}
+
+// bruteRangeSum adds up a rectangle of a grid cell by cell, for comparison with RangeSum.
+func bruteRangeSum(grid Grid[int], topLeft, bottomRight Coordinate) (sum int) {
+	for y := topLeft.Y; y <= bottomRight.Y; y++ {
+		for x := topLeft.X; x <= bottomRight.X; x++ {
+			sum += grid[y][x]
+		}
+	}
+	return
+}
+
+func TestRangeSum(t *testing.T) {
+	grid := Grid[int]{
+		{1, 2, 3, 4},
+		{5, 6, 7, 8},
+		{9, 10, 11, 12},
+	}
+	table := PrefixSum2D(grid)
+	cases := []struct {
+		name                 string
+		topLeft, bottomRight Coordinate
+		want                 int
+	}{
+		{"top-left cell", Coordinate{0, 0}, Coordinate{0, 0}, 1},
+		{"bottom-right cell", Coordinate{3, 2}, Coordinate{3, 2}, 12},
+		{"interior cell", Coordinate{1, 1}, Coordinate{1, 1}, 6},
+		{"full grid", Coordinate{0, 0}, Coordinate{3, 2}, 78},
+		{"top row", Coordinate{0, 0}, Coordinate{3, 0}, 10},
+		{"bottom row", Coordinate{0, 2}, Coordinate{3, 2}, 42},
+		{"left column", Coordinate{0, 0}, Coordinate{0, 2}, 15},
+		{"right column", Coordinate{3, 0}, Coordinate{3, 2}, 24},
+		{"touching right and bottom edges", Coordinate{2, 1}, Coordinate{3, 2}, 38},
+		{"touching left edge only", Coordinate{0, 1}, Coordinate{1, 2}, 30},
+		{"touching top edge only", Coordinate{1, 0}, Coordinate{2, 1}, 18},
+	}
+	for _, c := range cases {
+		if got := RangeSum(table, c.topLeft, c.bottomRight); got != c.want || got != bruteRangeSum(grid, c.topLeft, c.bottomRight) {
+			t.Errorf("%s: RangeSum(%v, %v) = %d, want %d", c.name, c.topLeft, c.bottomRight, got, c.want)
+		}
+	}
+	for y1 := range grid {
+		for x1 := range grid[0] {
+			for y2 := y1; y2 < len(grid); y2++ {
+				for x2 := x1; x2 < len(grid[0]); x2++ {
+					tl, br := Coordinate{x1, y1}, Coordinate{x2, y2}
+					if got, want := RangeSum(table, tl, br), bruteRangeSum(grid, tl, br); got != want {
+						t.Fatalf("RangeSum(%v, %v) = %d, want %d", tl, br, got, want)
+					}
+				}
+			}
+		}
+	}
+	single := PrefixSum2D(Grid[int]{{7}})
+	if got := RangeSum(single, Coordinate{0, 0}, Coordinate{0, 0}); got != 7 {
+		t.Errorf("RangeSum of a 1x1 grid = %d, want 7", got)
+	}
+}
+
+func TestPrefixCount2D(t *testing.T) {
+	grid := Grid[bool]{
+		{true, false, true},
+		{false, true, true},
+	}
+	table := PrefixCount2D(grid)
+	if got := RangeSum(table, Coordinate{0, 0}, Coordinate{2, 1}); got != 4 {
+		t.Errorf("count of full grid = %d, want 4", got)
+	}
+	if got := RangeSum(table, Coordinate{1, 0}, Coordinate{2, 1}); got != 3 {
+		t.Errorf("count of right two columns = %d, want 3", got)
+	}
+	if got := RangeSum(table, Coordinate{1, 0}, Coordinate{1, 0}); got != 0 {
+		t.Errorf("count of a false cell = %d, want 0", got)
+	}
+}
+
+// renderOCR draws text in the 6-row font with each letter at the left of its own cell of the given width.
+func renderOCR(text string, cellWidth int) Grid[rune] {
+	glyphs := make(map[rune][]string)
+	for glyph, letter := range ocrFont {
+		glyphs[letter] = strings.Split(glyph, "\n")
+	}
+	g := make(Grid[rune], len(ocrRows))
+	for y := range g {
+		g[y] = []rune(strings.Repeat(".", cellWidth*len(text)))
+	}
+	for i, letter := range text {
+		for y, row := range glyphs[letter] {
+			copy(g[y][i*cellWidth:], []rune(row))
+		}
+	}
+	return g
+}
+
+func TestOCR(t *testing.T) {
+	for _, text := range []string{"YA", "AY", "YYZ", "EARKJHRK", "IJ", ocrLetters} {
+		if got := OCR(renderOCR(text, ocrCellWidth), '#'); got != text {
+			t.Errorf("OCR of %q in 5-column cells = %q", text, got)
+		}
+	}
+	// Letters spaced more widely than the standard cells are still read by splitting on blank columns.
+	if got := OCR(renderOCR("HELLO", 7), '#'); got != "HELLO" {
+		t.Errorf("OCR of \"HELLO\" in 7-column cells = %q", got)
+	}
+	padded := append(Grid[rune]{[]rune("..........")}, renderOCR("FU", ocrCellWidth)...)
+	padded = append(padded, []rune(".........."))
+	if got := OCR(padded, '#'); got != "FU" {
+		t.Errorf("OCR with blank rows around the text = %q, want \"FU\"", got)
+	}
+	unknown := renderOCR("AA", ocrCellWidth)
+	unknown[0][5] = '#'
+	if got := OCR(unknown, '#'); got != "A?" {
+		t.Errorf("OCR with a damaged glyph = %q, want \"A?\"", got)
+	}
+	expectPanic(t, "grid has no lit cells", func() { OCR(Grid[rune]{[]rune("....")}, '#') })
+	expectPanic(t, "text is 5 rows tall", func() { OCR(renderOCR("A", ocrCellWidth)[1:], '#') })
+}
+
+func TestCounterMostCommon(t *testing.T) {
+	c := CountRunes("NNCBCHBN")
+	want := []ItemCount[rune]{{'N', 3}, {'B', 2}, {'C', 2}, {'H', 1}}
+	if got := c.MostCommon(-1, cmp.Compare); !slices.Equal(got, want) {
+		t.Errorf("MostCommon(-1) = %v, want %v", got, want)
+	}
+	if got := c.MostCommon(2, cmp.Compare); !slices.Equal(got, want[:2]) {
+		t.Errorf("MostCommon(2) = %v, want %v", got, want[:2])
+	}
+	if got := c.MostCommon(10, nil); len(got) != 4 || got[0] != want[0] || got[3] != want[3] {
+		t.Errorf("MostCommon(10, nil) = %v, want counts 3, 2, 2, 1", got)
+	}
+
+	// Items that aren't ordered work too, with any tie-breaking rule.
+	visits := make(Counter[Coordinate])
+	visits.AddN(Coordinate{X: 1, Y: 2}, 4)
+	visits.AddN(Coordinate{X: 0, Y: 5}, 4)
+	visits.Add(Coordinate{X: 3, Y: 3})
+	byY := func(a, b Coordinate) int { return cmp.Compare(a.Y, b.Y) }
+	wantVisits := []ItemCount[Coordinate]{{Coordinate{1, 2}, 4}, {Coordinate{0, 5}, 4}, {Coordinate{3, 3}, 1}}
+	if got := visits.MostCommon(-1, byY); !slices.Equal(got, wantVisits) {
+		t.Errorf("MostCommon on coordinates = %v, want %v", got, wantVisits)
+	}
+	if got := (Counter[[2]rune]{{'A', 'B'}: 1}).MostCommon(1, nil); len(got) != 1 || got[0].Count != 1 {
+		t.Errorf("MostCommon on rune pairs = %v", got)
+	}
+}
+
+func TestMostCommonOrdered(t *testing.T) {
+	c := CountRunes("NNCBCHBN")
+	want := []ItemCount[rune]{{'N', 3}, {'B', 2}, {'C', 2}, {'H', 1}}
+	if got := MostCommonOrdered(c, -1); !slices.Equal(got, want) {
+		t.Errorf("MostCommonOrdered(-1) = %v, want %v", got, want)
+	}
+
+	// A tie that straddles the cutoff must keep the same items on every run, not whichever the map yields first.
+	words := Counter[string]{"delta": 2, "alpha": 3, "charlie": 2, "bravo": 2, "echo": 1}
+	wantWords := []ItemCount[string]{{"alpha", 3}, {"bravo", 2}}
+	for range 50 {
+		if got := MostCommonOrdered(words, 2); !slices.Equal(got, wantWords) {
+			t.Fatalf("MostCommonOrdered(2) = %v, want %v", got, wantWords)
+		}
+		if got := words.MostCommon(3, cmp.Compare); !slices.Equal(got, []ItemCount[string]{{"alpha", 3}, {"bravo", 2}, {"charlie", 2}}) {
+			t.Fatalf("MostCommon(3, cmp.Compare) = %v, want alpha, bravo, charlie", got)
+		}
+	}
+}
+
+func TestCounterMergeSubtract(t *testing.T) {
+	c := CountRunes("aab")
+	c.Merge(CountRunes("bc"))
+	if c['a'] != 2 || c['b'] != 2 || c['c'] != 1 || c.Total() != 5 {
+		t.Fatalf("after Merge counter = %v", c)
+	}
+	c.Subtract(CountRunes("abbd"))
+	if _, ok := c['b']; ok {
+		t.Errorf("item counted down to 0 was kept: %v", c)
+	}
+	if c['a'] != 1 || c['c'] != 1 || c['d'] != -1 {
+		t.Errorf("after Subtract counter = %v, want a:1 c:1 d:-1", c)
+	}
+}
+
+func TestBitsRoundTrip(t *testing.T) {
+	cases := []struct {
+		s     string
+		num   int
+		width int
+	}{
+		{"1011", 11, 4},
+		{"1101", 13, 4},
+		{"0001", 1, 4},
+		{"1000", 8, 4},
+		{"00101100", 44, 8},
+		{"0", 0, 1},
+		{"111111111111111111111111111111111111111111111111111111111111111", math.MaxInt, 63},
+	}
+	for _, c := range cases {
+		bits := make([]bool, len(c.s))
+		for i, r := range c.s {
+			bits[i] = r == '1'
+		}
+		if got := BitsToInt(bits); got != c.num {
+			t.Errorf("BitsToInt(%s) = %d, want %d", c.s, got, c.num)
+		}
+		if got := BoolsToString(IntToBits(c.num, c.width), '1', '0'); got != c.s {
+			t.Errorf("IntToBits(%d, %d) = %s, want %s", c.num, c.width, got, c.s)
+		}
+		if got := BoolsToString(bits, '#', '.'); got != strings.NewReplacer("1", "#", "0", ".").Replace(c.s) {
+			t.Errorf("BoolsToString(%s) = %s", c.s, got)
+		}
+	}
+	// Numbers wider than width keep every bit rather than being truncated.
+	if got := BoolsToString(IntToBits(13, 2), '1', '0'); got != "1101" {
+		t.Errorf("IntToBits(13, 2) = %s, want 1101", got)
+	}
+	if got := BoolsToString(IntToBits(0, 0), '1', '0'); got != "0" {
+		t.Errorf("IntToBits(0, 0) = %s, want 0", got)
+	}
+	expectPanic(t, "64 bits overflows int", func() { BitsToInt(make([]bool, 64)) })
+	expectPanic(t, "must be non-negative", func() { IntToBits(-1, 4) })
+}
+
+func TestSplitAny(t *testing.T) {
+	cases := []struct {
+		s, delims string
+		want      []string
+	}{
+		{"1-3 a: abcde", "- :", []string{"1", "3", "a", "abcde"}},
+		{"--1-3 a: abcde::", "- :", []string{"1", "3", "a", "abcde"}},
+		{"a,,b;;c", ",;", []string{"a", "b", "c"}},
+		{"abc", ",", []string{"abc"}},
+		{",,,", ",", []string{}},
+		{"", ",", []string{}},
+	}
+	for _, c := range cases {
+		if got := SplitAny(c.s, c.delims); !slices.Equal(got, c.want) {
+			t.Errorf("SplitAny(%q, %q) = %q, want %q", c.s, c.delims, got, c.want)
+		}
+	}
+}
+
+func TestSplitAnyInts(t *testing.T) {
+	cases := []struct {
+		s, delims string
+		want      []int
+	}{
+		{"x=-3, y=12..-7", "xy=, .", []int{-3, 12, -7}},
+		{"  4,,-5 ,6  ", ", ", []int{4, -5, 6}},
+		{"-1", ",", []int{-1}},
+		{"", ",", []int{}},
+	}
+	for _, c := range cases {
+		if got := SplitAnyInts(c.s, c.delims); !slices.Equal(got, c.want) {
+			t.Errorf("SplitAnyInts(%q, %q) = %v, want %v", c.s, c.delims, got, c.want)
+		}
+	}
+}
+
+func TestGCD(t *testing.T) {
+	cases := []struct{ a, b, want int }{
+		{0, 0, 0},
+		{12, 18, 6},
+		{-12, 18, 6},
+		{12, -18, 6},
+		{-12, -18, 6},
+		{7, 0, 7},
+		{0, -7, 7},
+		{17, 5, 1},
+		{math.MaxInt, math.MaxInt, math.MaxInt},
+		{math.MinInt, 6, 2},
+		{math.MinInt, 1 << 40, 1 << 40},
+		{math.MinInt, math.MaxInt, 1},
+		{-(1 << 62), math.MinInt, 1 << 62},
+	}
+	for _, c := range cases {
+		if got := GCD(c.a, c.b); got != c.want {
+			t.Errorf("GCD(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+	expectPanic(t, "GCD: gcd(", func() { GCD(math.MinInt, 0) })
+	expectPanic(t, "GCD: gcd(", func() { GCD(math.MinInt, math.MinInt) })
+}
+
+func TestLCM(t *testing.T) {
+	cases := []struct{ a, b, want int }{
+		{0, 5, 0},
+		{5, 0, 0},
+		{4, 6, 12},
+		{-4, 6, 12},
+		{4, -6, 12},
+		{1 << 61, 3, 3 << 61},
+		{math.MaxInt, 1, math.MaxInt},
+		{math.MaxInt, -1, math.MaxInt},
+	}
+	for _, c := range cases {
+		if got := LCM(c.a, c.b); got != c.want {
+			t.Errorf("LCM(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+	expectPanic(t, "LCM: lcm(", func() { LCM(math.MinInt, 1) })
+	expectPanic(t, "LCM: lcm(", func() { LCM(2, math.MinInt) })
+	expectPanic(t, "LCM: lcm(", func() { LCM(1<<62, 3) })
+	expectPanic(t, "LCM: lcm(", func() { LCM(math.MaxInt, math.MaxInt-1) })
+}
+
+func TestSign(t *testing.T) {
+	cases := []struct{ x, want int }{
+		{0, 0},
+		{1, 1},
+		{-1, -1},
+		{42, 1},
+		{-42, -1},
+		{math.MaxInt, 1},
+		{math.MinInt, -1},
+	}
+	for _, c := range cases {
+		if got := Sign(c.x); got != c.want {
+			t.Errorf("Sign(%d) = %d, want %d", c.x, got, c.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cases := []struct{ a, b, want int }{
+		{0, 0, 0},
+		{1, 2, -1},
+		{2, 1, 1},
+		{-5, -5, 0},
+		{math.MinInt, math.MaxInt, -1},
+		{math.MaxInt, math.MinInt, 1},
+		{math.MinInt, 1, -1},
+		{1, math.MinInt, 1},
+		{math.MinInt, math.MinInt, 0},
+	}
+	for _, c := range cases {
+		if got := Compare(c.a, c.b); got != c.want {
+			t.Errorf("Compare(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+	if got := Compare("abc", "abd"); got != -1 {
+		t.Errorf(`Compare("abc", "abd") = %d, want -1`, got)
+	}
+	if got := Compare(2.5, -1.0); got != 1 {
+		t.Errorf("Compare(2.5, -1.0) = %d, want 1", got)
+	}
+}
+
+func TestMod(t *testing.T) {
+	cases := []struct{ a, b, want int }{
+		{7, 5, 2},
+		{-1, 5, 4},
+		{-5, 5, 0},
+		{5, 5, 0},
+		{-1, -5, 4},
+		{5, -5, 0},
+		{-5, -5, 0},
+		{-6, 5, 4},
+		{-6, -5, 4},
+		{0, -5, 0},
+		{math.MinInt, 7, 6},
+		{math.MinInt, 2, 0},
+		{math.MinInt, -1, 0},
+		{math.MinInt, math.MaxInt, math.MaxInt - 1},
+		{math.MinInt, math.MinInt, 0},
+		{-1, math.MinInt, math.MaxInt},
+		{5, math.MinInt, 5},
+		{math.MaxInt, math.MinInt, math.MaxInt},
+	}
+	for _, c := range cases {
+		if got := Mod(c.a, c.b); got != c.want {
+			t.Errorf("Mod(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+	expectPanic(t, "Mod: division by zero", func() { Mod(1, 0) })
+}
+
+func TestWrapIndex(t *testing.T) {
+	cases := []struct{ i, length, want int }{
+		{0, 4, 0},
+		{3, 4, 3},
+		{4, 4, 0},
+		{-1, 4, 3},
+		{-4, 4, 0},
+		{-5, 4, 3},
+		{9, 4, 1},
+		{0, 1, 0},
+		{math.MinInt, 3, 1},
+		{math.MaxInt, 3, 1},
+	}
+	for _, c := range cases {
+		if got := WrapIndex(c.i, c.length); got != c.want {
+			t.Errorf("WrapIndex(%d, %d) = %d, want %d", c.i, c.length, got, c.want)
+		}
+	}
+	expectPanic(t, "WrapIndex: length must be positive, got 0", func() { WrapIndex(1, 0) })
+	expectPanic(t, "WrapIndex: length must be positive, got -3", func() { WrapIndex(1, -3) })
+}
+
+func TestMidpoint(t *testing.T) {
+	cases := []struct{ a, b, want int }{
+		{0, 0, 0},
+		{0, 10, 5},
+		{0, 9, 4},
+		{9, 0, 5},
+		{-9, 0, -5},
+		{0, -9, -4},
+		{-3, 4, 0},
+		{4, -3, 1},
+		{math.MaxInt - 2, math.MaxInt, math.MaxInt - 1},
+		{math.MinInt, math.MinInt + 2, math.MinInt + 1},
+		{math.MinInt, math.MaxInt, -1},
+		{math.MaxInt, math.MinInt, 0},
+		{-1 << 62, 1 << 62, 0},
+		{math.MinInt, 0, -1 << 62},
+	}
+	for _, c := range cases {
+		if got := Midpoint(c.a, c.b); got != c.want {
+			t.Errorf("Midpoint(%d, %d) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestManhattanDistance(t *testing.T) {
+	cases := []struct {
+		a, b Coordinate
+		want int
+	}{
+		{Coordinate{0, 0}, Coordinate{0, 0}, 0},
+		{Coordinate{1, 2}, Coordinate{4, 6}, 7},
+		{Coordinate{-1, -2}, Coordinate{-4, -6}, 7},
+		{Coordinate{-3, 4}, Coordinate{2, -1}, 10},
+		{Coordinate{5, -5}, Coordinate{-5, 5}, 20},
+		{Coordinate{-7, 0}, Coordinate{0, 0}, 7},
+	}
+	for _, c := range cases {
+		if got := ManhattanDistance(c.a, c.b); got != c.want {
+			t.Errorf("ManhattanDistance(%v, %v) = %d, want %d", c.a, c.b, got, c.want)
+		}
+		if got := c.a.ManhattanTo(c.b); got != c.want {
+			t.Errorf("%v.ManhattanTo(%v) = %d, want %d", c.a, c.b, got, c.want)
+		}
+		if got := c.b.ManhattanTo(c.a); got != c.want {
+			t.Errorf("%v.ManhattanTo(%v) = %d, want %d", c.b, c.a, got, c.want)
+		}
+	}
+}
+
+func TestChebyshevDistanceTouching(t *testing.T) {
+	cases := []struct {
+		a, b     Coordinate
+		dist     int
+		touching bool
+	}{
+		{Coordinate{3, 3}, Coordinate{3, 3}, 0, true},
+		{Coordinate{-2, -2}, Coordinate{-2, -2}, 0, true},
+		{Coordinate{3, 3}, Coordinate{4, 3}, 1, true},
+		{Coordinate{3, 3}, Coordinate{3, 2}, 1, true},
+		{Coordinate{3, 3}, Coordinate{4, 4}, 1, true},
+		{Coordinate{3, 3}, Coordinate{2, 4}, 1, true},
+		{Coordinate{0, 0}, Coordinate{-1, -1}, 1, true},
+		{Coordinate{3, 3}, Coordinate{5, 3}, 2, false},
+		{Coordinate{3, 3}, Coordinate{5, 5}, 2, false},
+		{Coordinate{3, 3}, Coordinate{4, 5}, 2, false},
+		{Coordinate{0, 0}, Coordinate{-2, 1}, 2, false},
+		{Coordinate{-4, 7}, Coordinate{2, 4}, 6, false},
+	}
+	for _, c := range cases {
+		if got := ChebyshevDistance(c.a, c.b); got != c.dist {
+			t.Errorf("ChebyshevDistance(%v, %v) = %d, want %d", c.a, c.b, got, c.dist)
+		}
+		if got := Touching(c.a, c.b); got != c.touching {
+			t.Errorf("Touching(%v, %v) = %t, want %t", c.a, c.b, got, c.touching)
+		}
+		if got := Touching(c.b, c.a); got != c.touching {
+			t.Errorf("Touching(%v, %v) = %t, want %t", c.b, c.a, got, c.touching)
+		}
+	}
+}
+
+func TestBinarySearch(t *testing.T) {
+	cases := []struct {
+		name      string
+		lo, hi, k int
+		want      int
+	}{
+		{"middle", 0, 100, 37, 37},
+		{"first", 0, 100, 0, 0},
+		{"last", 0, 100, 100, 100},
+		{"none", 0, 100, 101, 101},
+		{"all", 0, 100, -5, 0},
+		{"single true", 4, 4, 4, 4},
+		{"single false", 4, 4, 5, 5},
+		{"negative", -100, -1, -42, -42},
+		{"mixed sign", -1 << 62, 1 << 62, 7, 7},
+		{"full range", math.MinInt, math.MaxInt, -3, -3},
+		{"full range first", math.MinInt, math.MaxInt, math.MinInt, math.MinInt},
+		{"max hi", 0, math.MaxInt, math.MaxInt - 1, math.MaxInt - 1},
+		{"max hi last", 0, math.MaxInt, math.MaxInt, math.MaxInt},
+		{"min lo", math.MinInt, 0, math.MinInt + 1, math.MinInt + 1},
+	}
+	for _, c := range cases {
+		calls := 0
+		pred := func(i int) bool {
+			calls++
+			if i < c.lo || i > c.hi {
+				t.Fatalf("%s: pred(%d) called outside [%d, %d]", c.name, i, c.lo, c.hi)
+			}
+			return i >= c.k
+		}
+		if got := BinarySearch(c.lo, c.hi, pred); got != c.want {
+			t.Errorf("%s: BinarySearch(%d, %d, i >= %d) = %d, want %d", c.name, c.lo, c.hi, c.k, got, c.want)
+		}
+		if calls > 66 {
+			t.Errorf("%s: pred called %d times, want at most 66", c.name, calls)
+		}
+	}
+	// With nothing found, hi+1 wraps around when hi is math.MaxInt.
+	if got := BinarySearch(0, math.MaxInt, func(int) bool { return false }); got != math.MinInt {
+		t.Errorf("BinarySearch(0, MaxInt, false) = %d, want MinInt", got)
+	}
+	if got := BinarySearch(5, 3, func(int) bool { t.Fatal("pred called on an empty range"); return true }); got != 4 {
+		t.Errorf("BinarySearch(5, 3) = %d, want 4", got)
+	}
+}
+
+func TestFactorial(t *testing.T) {
+	cases := []struct{ n, want int }{
+		{0, 1},
+		{1, 1},
+		{2, 2},
+		{5, 120},
+		{10, 3628800},
+		{20, 2432902008176640000},
+	}
+	for _, c := range cases {
+		if got := Factorial(c.n); got != c.want {
+			t.Errorf("Factorial(%d) = %d, want %d", c.n, got, c.want)
+		}
+	}
+	expectPanic(t, "overflows int", func() { Factorial(21) })
+	expectPanic(t, "Factorial: n must be non-negative", func() { Factorial(-1) })
+}
+
+func TestBinomial(t *testing.T) {
+	cases := []struct{ n, k, want int }{
+		{0, 0, 1},
+		{5, 0, 1},
+		{5, 5, 1},
+		{5, 1, 5},
+		{5, 2, 10},
+		{5, 3, 10},
+		{10, 3, 120},
+		{52, 5, 2598960},
+		{60, 30, 118264581564861424},
+		{66, 33, 7219428434016265740},
+		{67, 1, 67},
+		{100, 99, 100},
+	}
+	for _, c := range cases {
+		if got := Binomial(c.n, c.k); got != c.want {
+			t.Errorf("Binomial(%d, %d) = %d, want %d", c.n, c.k, got, c.want)
+		}
+	}
+	expectPanic(t, "overflows int", func() { Binomial(67, 33) })
+	expectPanic(t, "Binomial: invalid arguments n=-1, k=0", func() { Binomial(-1, 0) })
+	expectPanic(t, "Binomial: invalid arguments n=5, k=-1", func() { Binomial(5, -1) })
+	expectPanic(t, "Binomial: invalid arguments n=3, k=4", func() { Binomial(3, 4) })
+}
+
+func TestPermutationCount(t *testing.T) {
+	cases := []struct{ n, k, want int }{
+		{0, 0, 1},
+		{5, 0, 1},
+		{5, 1, 5},
+		{10, 3, 720},
+		{5, 5, 120},
+		{20, 20, 2432902008176640000},
+	}
+	for _, c := range cases {
+		if got := PermutationCount(c.n, c.k); got != c.want {
+			t.Errorf("PermutationCount(%d, %d) = %d, want %d", c.n, c.k, got, c.want)
+		}
+	}
+	expectPanic(t, "overflows int", func() { PermutationCount(21, 21) })
+	expectPanic(t, "PermutationCount: invalid arguments n=-1, k=0", func() { PermutationCount(-1, 0) })
+	expectPanic(t, "PermutationCount: invalid arguments n=5, k=-1", func() { PermutationCount(5, -1) })
+	expectPanic(t, "PermutationCount: invalid arguments n=3, k=4", func() { PermutationCount(3, 4) })
+}
+
+func TestSingleBits(t *testing.T) {
+	for i := range 64 {
+		n := 1 << i
+		if i == 63 && n != math.MinInt {
+			t.Fatalf("1<<63 = %d, want math.MinInt", n)
+		}
+		if got := PopCount(n); got != 1 {
+			t.Errorf("PopCount(1<<%d) = %d, want 1", i, got)
+		}
+		for j := range 64 {
+			if got := HasBit(n, j); got != (i == j) {
+				t.Errorf("HasBit(1<<%d, %d) = %t, want %t", i, j, got, i == j)
+			}
+			if got := HasBit(^n, j); got != (i != j) {
+				t.Errorf("HasBit(^(1<<%d), %d) = %t, want %t", i, j, got, i != j)
+			}
+		}
+		if got := SetBit(0, i); got != n {
+			t.Errorf("SetBit(0, %d) = %d, want %d", i, got, n)
+		}
+		if got := SetBit(n, i); got != n {
+			t.Errorf("SetBit(1<<%d, %d) = %d, want %d", i, i, got, n)
+		}
+		if got := ClearBit(n, i); got != 0 {
+			t.Errorf("ClearBit(1<<%d, %d) = %d, want 0", i, i, got)
+		}
+		if got := ClearBit(-1, i); got != ^n {
+			t.Errorf("ClearBit(-1, %d) = %d, want %d", i, got, ^n)
+		}
+		if got := ToggleBit(0, i); got != n {
+			t.Errorf("ToggleBit(0, %d) = %d, want %d", i, got, n)
+		}
+		if got := ToggleBit(n, i); got != 0 {
+			t.Errorf("ToggleBit(1<<%d, %d) = %d, want 0", i, i, got)
+		}
+		if got := ToggleBit(-1, i); got != ^n {
+			t.Errorf("ToggleBit(-1, %d) = %d, want %d", i, got, ^n)
+		}
+		if got := MaskRange(i, i); got != n {
+			t.Errorf("MaskRange(%d, %d) = %d, want %d", i, i, got, n)
+		}
+		if got := BitsSet(n); !slices.Equal(got, []int{i}) {
+			t.Errorf("BitsSet(1<<%d) = %v, want [%d]", i, got, i)
+		}
+	}
+}
+
+func TestBitsZeroAndMinusOne(t *testing.T) {
+	if got := PopCount(0); got != 0 {
+		t.Errorf("PopCount(0) = %d, want 0", got)
+	}
+	if got := PopCount(-1); got != 64 {
+		t.Errorf("PopCount(-1) = %d, want 64", got)
+	}
+	if got := PopCount(math.MaxInt); got != 63 {
+		t.Errorf("PopCount(MaxInt) = %d, want 63", got)
+	}
+	if got := BitsSet(0); len(got) != 0 {
+		t.Errorf("BitsSet(0) = %v, want []", got)
+	}
+	all := make([]int, 64)
+	for i := range all {
+		all[i] = i
+	}
+	if got := BitsSet(-1); !slices.Equal(got, all) {
+		t.Errorf("BitsSet(-1) = %v, want 0..63", got)
+	}
+	if got := BitsSet(0b101001); !slices.Equal(got, []int{0, 3, 5}) {
+		t.Errorf("BitsSet(0b101001) = %v, want [0 3 5]", got)
+	}
+}
+
+func TestMaskRange(t *testing.T) {
+	cases := []struct{ lo, hi, want int }{
+		{0, 0, 1},
+		{0, 3, 0b1111},
+		{2, 4, 0b11100},
+		{0, 62, math.MaxInt},
+		{0, 63, -1},
+		{1, 63, -2},
+		{63, 63, math.MinInt},
+		{62, 63, math.MinInt | 1<<62},
+	}
+	for _, c := range cases {
+		if got := MaskRange(c.lo, c.hi); got != c.want {
+			t.Errorf("MaskRange(%d, %d) = %d, want %d", c.lo, c.hi, got, c.want)
+		}
+	}
+}
+
+func TestBitIndexPanics(t *testing.T) {
+	for _, i := range []int{-1, 64, math.MinInt, math.MaxInt} {
+		want := fmt.Sprintf("bit index %d out of range [0, 64)", i)
+		expectPanic(t, want, func() { HasBit(0, i) })
+		expectPanic(t, want, func() { SetBit(0, i) })
+		expectPanic(t, want, func() { ClearBit(0, i) })
+		expectPanic(t, want, func() { ToggleBit(0, i) })
+		expectPanic(t, want, func() { MaskRange(i, 3) })
+		expectPanic(t, want, func() { MaskRange(3, i) })
+	}
+	expectPanic(t, "MaskRange: lo 5 is greater than hi 4", func() { MaskRange(5, 4) })
+	expectPanic(t, "MaskRange: lo 63 is greater than hi 0", func() { MaskRange(63, 0) })
+}